@@ -7,23 +7,51 @@ import (
 	apppkg "mortgage-calculator/src/internal/app"
 	"mortgage-calculator/src/internal/config"
 	"mortgage-calculator/src/internal/logger"
-	"time"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
 	cfg := config.MustLoad()
 	log := logger.New(cfg.Env)
-	app := apppkg.New(log, cfg.Env, cfg.Port, int64(cfg.Cache.TTL))
+	app, err := apppkg.New(log, cfg)
+	if err != nil {
+		log.Error("failed to create application", slog.Any("error", err))
+		os.Exit(1)
+	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := app.Start(ctx); err != nil {
+		log.Error("failed to start application", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	served := make(chan error, 1)
 	go func() {
-		tick := time.NewTicker(time.Duration(cfg.Cache.Clear) * time.Second)
-		ctx := context.Background()
-		for {
-			<-tick.C
-			app.Cache.Clear(ctx)
-		}
+		served <- app.Server.Serve()
 	}()
 
-	err := app.Server.Serve()
-	log.Error("application has stopped: %s", slog.Any("error", err))
+	select {
+	case err = <-served:
+		log.Error("server has stopped", slog.Any("error", err))
+	case <-ctx.Done():
+		log.Info("received stop signal")
+	}
+
+	// background jobs and exporters must not delay exit beyond the timeout
+	stopCtx, cancel := context.WithTimeout(context.Background(), app.Server.ShutdownTimeout())
+	stopErr := app.Stop(stopCtx)
+	cancel()
+
+	if stopErr != nil {
+		log.Error("failed to stop application gracefully", slog.Any("error", stopErr))
+		os.Exit(1)
+	}
+
+	if err != nil {
+		os.Exit(1)
+	}
 }
@@ -3,45 +3,276 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	janitorapp "mortgage-calculator/src/internal/app/janitor"
 	serverapp "mortgage-calculator/src/internal/app/server"
+	"mortgage-calculator/src/internal/cache/file"
 	"mortgage-calculator/src/internal/cache/memory"
+	"mortgage-calculator/src/internal/cache/redis"
 	cacherepos "mortgage-calculator/src/internal/cache/repos"
+	"mortgage-calculator/src/internal/config"
 	"mortgage-calculator/src/internal/controllers"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/clock"
+	"mortgage-calculator/src/internal/lib/metrics"
+	"mortgage-calculator/src/internal/lib/money"
+	"mortgage-calculator/src/internal/lib/tracing"
 	"mortgage-calculator/src/internal/server"
 	"mortgage-calculator/src/internal/services"
+	"os"
+	"time"
 )
 
-type clearer interface {
-	Clear(ctx context.Context)
-}
-
 // App represents application.
 type App struct {
-	Server *serverapp.Server
-	Cache  clearer
+	Server  *serverapp.Server
+	Janitor *janitorapp.Janitor
+	Health  *services.HealthService
+	log     *slog.Logger
+	repo    *cacherepos.CalcRepository
+	cache   cacherepos.Cache
+	tracer  *tracing.Tracer
+}
+
+// defaultService names service in traces when name is not configured.
+const defaultService = "mortgage-calculator"
+
+// pinger is implemented by cache backends able to check their availability.
+type pinger interface {
+	Ping(ctx context.Context) error
 }
 
 // New creates all dependencies for App and returns new App instance.
 func New(
 	log *slog.Logger,
-	env string,
-	port int,
-	cacheTTL int64,
-) *App {
-	cache := memory.New(log, cacheTTL)
-	repo := cacherepos.NewCalcRepository(log, cache)
+	cfg *config.Config,
+) (*App, error) {
+	const op = "app.New"
+
+	clk := clock.System{}
+	cache, err := newCache(log, cfg.Cache, clk)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tracer, err := newTracer(log, cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	calcService := services.NewCalculatorService(
+		log,
+		programs(cfg.Programs),
+		currency(cfg.Money),
+		money.Rounding(cfg.Money.Rounding),
+		clk,
+	)
+
+	repo := cacherepos.NewCalcRepository(log, cache, clk, cacherepos.Refresh{
+		TTL:   time.Duration(cfg.Cache.TTL) * time.Second,
+		Ahead: time.Duration(cfg.Cache.RefreshAhead) * time.Second,
+	}, calcService.Fingerprint())
+
+	cacheService := services.NewCacheService(log, repo)
 
-	calcService := services.NewCalculatorService(log)
+	healthService := newHealth(log, cache)
 
-	calcCon := controllers.NewCalcController(log, calcService, repo)
-	cacheCon := controllers.NewCacheController(log, repo)
+	calcCon := controllers.NewCalcController(log, calcService, repo, clk)
+	solverCon := controllers.NewSolverController(log, calcService)
+	cacheCon := controllers.NewCacheController(log, cacheService)
+	healthCon := controllers.NewHealthController(log, healthService)
 
-	router := server.NewRouter(log, env, calcCon, cacheCon)
-	serverApp := serverapp.New(log, port, router)
+	reg := metrics.NewRegistry(log)
+	reg.Register(cacherepos.NewCollector(repo, programIDs(calcService.Programs())))
+
+	router := server.NewRouter(log, cfg.Env, calcCon, solverCon, cacheCon, healthCon, reg, tracer)
+	serverApp := serverapp.New(log, cfg.Port, router, timeouts(cfg.HTTP))
+
+	janitor := janitorapp.New(
+		log,
+		repo,
+		time.Duration(cfg.Cache.Clear)*time.Second,
+		time.Duration(cfg.Cache.ClearJitter)*time.Second,
+	)
 
 	return &App{
-		Server: serverApp,
-		Cache:  repo,
+		Server:  serverApp,
+		Janitor: janitor,
+		Health:  healthService,
+		log:     log,
+		repo:    repo,
+		cache:   cache,
+		tracer:  tracer,
+	}, nil
+}
+
+// Start starts background jobs and reports that application is ready.
+func (a *App) Start(ctx context.Context) error {
+	const op = "app.Start"
+
+	if err := a.Janitor.Start(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.Health.MarkRunning()
+
+	return nil
+}
+
+// Stop reports that application is not ready, drains in-flight requests, stops cache janitor,
+// waits for background refreshes of cache entries, exports remaining spans and closes cache backend.
+// Every step is performed even when previous one fails, ctx limits the whole stop.
+func (a *App) Stop(ctx context.Context) error {
+	const op = "app.Stop"
+	log := a.log.With(slog.String("op", op))
+
+	log.Info("stopping application")
+	a.Health.MarkStopping()
+
+	var errs []error
+	if err := a.Server.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	a.Janitor.Stop()
+
+	if err := a.repo.Wait(ctx); err != nil {
+		log.Error("failed to wait for cache refreshes", slog.Any("error", err))
+		errs = append(errs, err)
+	}
+
+	if err := a.tracer.Shutdown(ctx); err != nil {
+		log.Error("failed to export remaining spans", slog.Any("error", err))
+		errs = append(errs, err)
+	}
+
+	// persistent backends flush their state on close
+	if closer, ok := a.cache.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Error("failed to close cache", slog.Any("error", err))
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("application stopped")
+
+	return nil
+}
+
+// newCache creates cache of configured driver.
+func newCache(log *slog.Logger, cfg config.Cache, clk clock.Clock) (cacherepos.Cache, error) {
+	switch cfg.Driver {
+	case config.CacheDriverRedis:
+		return redis.New(log, int64(cfg.TTL), redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+			Prefix:   cfg.Redis.Prefix,
+			PoolSize: cfg.Redis.PoolSize,
+			Timeout:  time.Duration(cfg.Redis.Timeout) * time.Second,
+		}), nil
+	case config.CacheDriverFile:
+		return file.New(log, int64(cfg.TTL), cfg.File.Path) //nolint:wrapcheck // wrapped by caller
+	default:
+		return memory.New(log, int64(cfg.TTL), memory.Limits{
+			MaxEntries: cfg.MaxEntries,
+			MaxBytes:   cfg.MaxBytes,
+			Policy:     memory.Policy(cfg.Eviction),
+		}, clk), nil
+	}
+}
+
+// newHealth creates health service checking availability of cache backend when it is able to.
+func newHealth(log *slog.Logger, cache cacherepos.Cache) *services.HealthService {
+	health := services.NewHealthService(log, services.DefaultHealthTimeout)
+	if p, ok := cache.(pinger); ok {
+		health.Register("cache", services.HealthCheckerFunc(p.Ping))
+	}
+
+	return health
+}
+
+// newTracer creates tracer exporting spans with configured exporter, tracer of none exporter only propagates traces.
+func newTracer(log *slog.Logger, cfg config.Tracing) (*tracing.Tracer, error) {
+	service := cfg.Service
+	if service == "" {
+		service = defaultService
+	}
+
+	var exporter tracing.Exporter
+	switch cfg.Exporter {
+	case config.TracingExporterStdout:
+		exporter = tracing.NewWriterExporter(os.Stdout)
+	case config.TracingExporterFile:
+		fileExporter, err := tracing.NewFileExporter(cfg.Path)
+		if err != nil {
+			return nil, err //nolint:wrapcheck // wrapped by caller
+		}
+		exporter = fileExporter
+	case config.TracingExporterOTLP:
+		exporter = tracing.NewOTLPExporter(tracing.OTLPOptions{
+			Endpoint: cfg.Endpoint,
+			Headers:  cfg.Headers,
+			Service:  service,
+			Timeout:  time.Duration(cfg.Timeout) * time.Second,
+		})
+	}
+
+	return tracing.NewTracer(log, exporter, tracing.Options{SampleRatio: cfg.SampleRatio}), nil
+}
+
+// timeouts converts configured timeouts of http server.
+func timeouts(cfg config.HTTP) serverapp.Timeouts {
+	return serverapp.Timeouts{
+		Read:     time.Duration(cfg.ReadTimeout) * time.Second,
+		Write:    time.Duration(cfg.WriteTimeout) * time.Second,
+		Idle:     time.Duration(cfg.IdleTimeout) * time.Second,
+		Shutdown: time.Duration(cfg.ShutdownTimeout) * time.Second,
+	}
+}
+
+// programs converts configured programs to domain programs.
+func programs(cfg []config.Program) []dto.Program {
+	res := make([]dto.Program, len(cfg))
+	for i, p := range cfg {
+		res[i] = dto.Program{
+			ID:                     p.ID,
+			Name:                   p.Name,
+			Rate:                   p.Rate,
+			MinInitialPaymentRatio: p.MinInitialPaymentRatio,
+			MinMonths:              p.MinMonths,
+			MaxMonths:              p.MaxMonths,
+			MinLoan:                money.Amount(p.MinLoan),
+			MaxLoan:                money.Amount(p.MaxLoan),
+			MaxObjectCost:          money.Amount(p.MaxObjectCost),
+			MaxDebtToIncome:        p.MaxDebtToIncome,
+		}
+	}
+
+	return res
+}
+
+// programIDs returns ids of programs, they label metrics of calculation requests.
+func programIDs(programs []dto.Program) []string {
+	res := make([]string, len(programs))
+	for i, p := range programs {
+		res[i] = p.ID
+	}
+
+	return res
+}
+
+// currency converts configured currency, zero value is kept to use default currency.
+func currency(cfg config.Money) money.Currency {
+	return money.Currency{
+		Code:       cfg.Currency,
+		MinorUnits: cfg.MinorUnits,
 	}
 }
@@ -1,15 +1,176 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/stretchr/testify/require"
 	"io"
 	"log/slog"
+	"mortgage-calculator/src/internal/cache/file"
+	"mortgage-calculator/src/internal/cache/memory"
+	"mortgage-calculator/src/internal/cache/redis"
+	"mortgage-calculator/src/internal/config"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/clock"
+	"mortgage-calculator/src/internal/lib/money"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
 	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
-	app := New(log, "dev", 1000, 1000)
+	app, err := New(log, &config.Config{
+		Env:  "dev",
+		Port: 1000,
+		Cache: config.Cache{
+			TTL:   1000,
+			Clear: 1000,
+		},
+	})
 
+	require.NoError(t, err)
 	require.NotEmpty(t, app)
 }
+
+func TestApp_Stop(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	app, err := New(log, &config.Config{
+		Env:  "dev",
+		Port: 0,
+		Cache: config.Cache{
+			TTL:    1000,
+			Clear:  1000,
+			Driver: config.CacheDriverFile,
+			File:   config.File{Path: filepath.Join(t.TempDir(), "cache.log")},
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, app.Start(context.Background()))
+	require.Equal(t, dto.HealthStatusOK, app.Health.Ready(context.Background()).Status)
+
+	require.NoError(t, app.Stop(context.Background()))
+	require.Equal(t, dto.HealthStatusFail, app.Health.Ready(context.Background()).Status)
+
+	// cache file is closed
+	c, ok := app.cache.(*file.Cache)
+	require.True(t, ok)
+	require.Error(t, c.Close())
+}
+
+func TestPrograms(t *testing.T) {
+	res := programs([]config.Program{
+		{ID: "family", Name: "Family", Rate: 0.06, MinInitialPaymentRatio: 0.15, MaxMonths: 360, MaxLoan: 6000000, MaxDebtToIncome: 0.4},
+	})
+
+	require.Equal(t, []dto.Program{
+		{ID: "family", Name: "Family", Rate: 0.06, MinInitialPaymentRatio: 0.15, MaxMonths: 360, MaxLoan: 6000000, MaxDebtToIncome: 0.4},
+	}, res)
+}
+
+func TestCurrency(t *testing.T) {
+	res := currency(config.Money{Currency: "USD", MinorUnits: 2, Rounding: "half_even"})
+	require.Equal(t, money.Currency{Code: "USD", MinorUnits: 2}, res)
+}
+
+func TestNewCache(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+
+	cases := []struct {
+		cfg  config.Cache
+		want any
+	}{
+		{cfg: config.Cache{TTL: 100}, want: &memory.Cache{}},
+		{
+			cfg:  config.Cache{TTL: 100, Driver: config.CacheDriverRedis, Redis: config.Redis{Addr: "localhost:6379"}},
+			want: &redis.Cache{},
+		},
+		{
+			cfg:  config.Cache{TTL: 100, Driver: config.CacheDriverFile, File: config.File{Path: filepath.Join(t.TempDir(), "cache.log")}},
+			want: &file.Cache{},
+		},
+	}
+
+	for _, tc := range cases {
+		cache, err := newCache(log, tc.cfg, clock.System{})
+		require.NoError(t, err)
+		require.IsType(t, tc.want, cache)
+	}
+
+	_, err := newCache(log, config.Cache{Driver: config.CacheDriverFile, File: config.File{Path: t.TempDir()}}, clock.System{})
+	require.Error(t, err)
+}
+
+func TestNewTracer(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+
+	for _, exporter := range []string{"", config.TracingExporterNone, config.TracingExporterStdout, config.TracingExporterOTLP} {
+		tracer, err := newTracer(log, config.Tracing{Exporter: exporter, Endpoint: "http://localhost:4318/v1/traces"})
+		require.NoError(t, err)
+		require.NoError(t, tracer.Shutdown(context.Background()))
+	}
+
+	_, err := newTracer(log, config.Tracing{Exporter: config.TracingExporterFile, Path: t.TempDir()})
+	require.Error(t, err)
+}
+
+func TestApp_Tracing(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	path := filepath.Join(t.TempDir(), "spans.log")
+
+	app, err := New(log, &config.Config{
+		Env:     "dev",
+		Port:    0,
+		Cache:   config.Cache{TTL: 1000},
+		Tracing: config.Tracing{Exporter: config.TracingExporterFile, Path: path},
+	})
+	require.NoError(t, err)
+	require.NoError(t, app.Start(context.Background()))
+
+	go func() { _ = app.Server.Serve() }()
+	require.Eventually(t, func() bool { return app.Server.Addr() != nil }, time.Second, time.Millisecond)
+
+	body := `{"object_cost":1000000,"initial_payment":200000,"months":12,"program":"salary"}`
+	req, err := http.NewRequestWithContext(
+		context.Background(), http.MethodPost, "http://"+app.Server.Addr().String()+"/execute", strings.NewReader(body),
+	)
+	require.NoError(t, err)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// remaining spans are exported on stop
+	require.NoError(t, app.Stop(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	names := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var span struct {
+			TraceID    string         `json:"trace_id"`
+			Name       string         `json:"name"`
+			Attributes map[string]any `json:"attributes"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &span))
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID)
+
+		names[span.Name] = fmt.Sprintf("%v/%v", span.Attributes["program"], span.Attributes["cache.hit"])
+	}
+
+	require.Equal(t, map[string]string{
+		"POST /execute":               "<nil>/<nil>",
+		"CalcController.Calculate":    "salary/<nil>",
+		"CalcRepository.Get":          "<nil>/false",
+		"CalculatorService.Calculate": "salary/<nil>",
+		"CalcRepository.Set":          "<nil>/<nil>",
+	}, names)
+}
@@ -0,0 +1,133 @@
+// Package janitorapp defines janitor application that deletes expired cache entries in background.
+package janitorapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrAlreadyStarted represents error when janitor is started twice.
+var ErrAlreadyStarted = errors.New("janitor is already started")
+
+// Clearer deletes expired entries and returns their number.
+type Clearer interface {
+	Clear(ctx context.Context) int
+}
+
+// Janitor periodically deletes expired cache entries.
+// Random jitter is added to every interval, so replicas sharing cache don't sweep it at the same time.
+type Janitor struct {
+	log      *slog.Logger
+	cache    Clearer
+	interval time.Duration
+	jitter   time.Duration
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New returns new janitor instance.
+// Non-positive interval disables janitor.
+func New(
+	log *slog.Logger,
+	cache Clearer,
+	interval time.Duration,
+	jitter time.Duration,
+) *Janitor {
+	return &Janitor{
+		log:      log,
+		cache:    cache,
+		interval: interval,
+		jitter:   jitter,
+	}
+}
+
+// Start runs sweeping in background until Stop is called or ctx is done.
+// Disabled janitor is not started.
+func (j *Janitor) Start(ctx context.Context) error {
+	const op = "janitorApp.Start"
+	log := j.log.With(slog.String("op", op))
+
+	if j.interval <= 0 {
+		log.Info("cache janitor is disabled")
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.done != nil {
+		return ErrAlreadyStarted
+	}
+
+	ctx, j.cancel = context.WithCancel(ctx)
+	j.done = make(chan struct{})
+
+	go j.run(ctx, j.done)
+
+	log.Info("cache janitor started", slog.Duration("interval", j.interval), slog.Duration("jitter", j.jitter))
+
+	return nil
+}
+
+// Stop stops sweeping and waits until running sweep finishes.
+// Stopped janitor may be started again.
+func (j *Janitor) Stop() {
+	j.mu.Lock()
+	cancel, done := j.cancel, j.done
+	j.cancel, j.done = nil, nil
+	j.mu.Unlock()
+
+	if done == nil {
+		return
+	}
+
+	cancel()
+	<-done
+}
+
+func (j *Janitor) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	timer := time.NewTimer(j.next())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			j.log.Info("cache janitor stopped")
+			return
+		case <-timer.C:
+			j.sweep(ctx)
+			timer.Reset(j.next())
+		}
+	}
+}
+
+// sweep deletes expired entries and logs their number.
+func (j *Janitor) sweep(ctx context.Context) {
+	const op = "janitorApp.sweep"
+
+	start := time.Now()
+	deleted := j.cache.Clear(ctx)
+
+	j.log.Info("expired cache entries swept",
+		slog.String("op", op),
+		slog.Int("deleted", deleted),
+		slog.Duration("duration", time.Since(start)),
+	)
+}
+
+// next returns interval to the next sweep.
+func (j *Janitor) next() time.Duration {
+	if j.jitter <= 0 {
+		return j.interval
+	}
+
+	return j.interval + rand.N(j.jitter) //nolint:gosec // jitter doesn't need secure randomness
+}
@@ -0,0 +1,87 @@
+package janitorapp
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingClearer counts sweeps.
+type countingClearer struct {
+	calls atomic.Int32
+}
+
+func (c *countingClearer) Clear(context.Context) int {
+	c.calls.Add(1)
+	return 1
+}
+
+func setup(interval, jitter time.Duration) (*Janitor, *countingClearer) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	cache := new(countingClearer)
+
+	return New(log, cache, interval, jitter), cache
+}
+
+func TestJanitor_Sweeps(t *testing.T) {
+	j, cache := setup(time.Millisecond, time.Millisecond)
+
+	require.NoError(t, j.Start(context.Background()))
+	require.Eventually(t, func() bool { return cache.calls.Load() >= 3 }, time.Second, time.Millisecond)
+
+	j.Stop()
+	stopped := cache.calls.Load()
+
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, stopped, cache.calls.Load())
+}
+
+func TestJanitor_Disabled(t *testing.T) {
+	j, cache := setup(0, time.Millisecond)
+
+	require.NoError(t, j.Start(context.Background()))
+	time.Sleep(10 * time.Millisecond)
+	j.Stop()
+
+	require.Zero(t, cache.calls.Load())
+}
+
+func TestJanitor_Start_Twice(t *testing.T) {
+	j, cache := setup(time.Millisecond, 0)
+
+	require.NoError(t, j.Start(context.Background()))
+	require.ErrorIs(t, j.Start(context.Background()), ErrAlreadyStarted)
+	j.Stop()
+
+	// janitor is restarted after stop
+	stopped := cache.calls.Load()
+	require.NoError(t, j.Start(context.Background()))
+	require.Eventually(t, func() bool { return cache.calls.Load() > stopped }, time.Second, time.Millisecond)
+	j.Stop()
+}
+
+func TestJanitor_ContextDone(t *testing.T) {
+	j, cache := setup(time.Millisecond, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, j.Start(ctx))
+	require.Eventually(t, func() bool { return cache.calls.Load() > 0 }, time.Second, time.Millisecond)
+
+	cancel()
+	// Stop returns once the loop has exited
+	j.Stop()
+	stopped := cache.calls.Load()
+
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, stopped, cache.calls.Load())
+}
+
+func TestJanitor_Stop_NotStarted(t *testing.T) {
+	j, _ := setup(time.Millisecond, 0)
+
+	require.NotPanics(t, j.Stop)
+}
@@ -2,48 +2,101 @@
 package serverapp
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"github.com/gin-gonic/gin"
 	"log/slog"
+	"net"
+	"net/http"
 	"strconv"
+	"sync"
+	"time"
 )
 
 var errServerStopped = errors.New("server has stopped")
 
+const (
+	defaultReadTimeout     = 10 * time.Second
+	defaultWriteTimeout    = 30 * time.Second
+	defaultIdleTimeout     = 60 * time.Second
+	defaultShutdownTimeout = 15 * time.Second
+)
+
+// Timeouts limit duration of connections, zero value of any timeout means its default.
+type Timeouts struct {
+	Read     time.Duration // Read limits reading of whole request including body.
+	Write    time.Duration // Write limits time from the end of request headers to the end of response.
+	Idle     time.Duration // Idle limits waiting for the next request on keep-alive connection.
+	Shutdown time.Duration // Shutdown limits draining of in-flight requests.
+}
+
 // Server listens on port for new http connections and passes them to router.
 type Server struct {
-	log    *slog.Logger
-	router *gin.Engine
-	port   int
+	log      *slog.Logger
+	srv      *http.Server
+	port     int
+	shutdown time.Duration
+
+	mu   sync.Mutex
+	addr net.Addr
 }
 
 // New returns new server instance.
 func New(
 	log *slog.Logger,
 	port int,
-	router *gin.Engine,
+	router http.Handler,
+	timeouts Timeouts,
 ) *Server {
+	if timeouts.Read <= 0 {
+		timeouts.Read = defaultReadTimeout
+	}
+	if timeouts.Write <= 0 {
+		timeouts.Write = defaultWriteTimeout
+	}
+	if timeouts.Idle <= 0 {
+		timeouts.Idle = defaultIdleTimeout
+	}
+	if timeouts.Shutdown <= 0 {
+		timeouts.Shutdown = defaultShutdownTimeout
+	}
+
 	return &Server{
-		log:    log,
-		port:   port,
-		router: router,
+		log:  log,
+		port: port,
+		srv: &http.Server{
+			Handler:           router,
+			ReadTimeout:       timeouts.Read,
+			ReadHeaderTimeout: timeouts.Read,
+			WriteTimeout:      timeouts.Write,
+			IdleTimeout:       timeouts.Idle,
+		},
+		shutdown: timeouts.Shutdown,
 	}
 }
 
 // Serve starts http server and returns error when server is stopped.
+// Nil is returned when server is stopped by Shutdown.
 func (s *Server) Serve() error {
 	const op = "serverApp.Serve"
 
 	log := s.log.With(slog.String("op", op))
 
-	addr := fmt.Sprintf(":%s", strconv.Itoa(s.port))
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%s", strconv.Itoa(s.port)))
+	if err != nil {
+		log.Error("failed to listen", slog.Any("error", err))
 
-	log.Info(fmt.Sprintf("listening on %s", addr))
+		return fmt.Errorf("%w: %s", errServerStopped, err.Error())
+	}
 
-	err := s.router.Run(addr)
+	s.mu.Lock()
+	s.addr = ln.Addr()
+	s.mu.Unlock()
 
-	if err == nil {
+	log.Info(fmt.Sprintf("listening on %s", ln.Addr()))
+
+	err = s.srv.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
 		return nil
 	}
 
@@ -51,3 +104,38 @@ func (s *Server) Serve() error {
 
 	return fmt.Errorf("%w: %s", errServerStopped, err.Error())
 }
+
+// Addr returns address server listens on, nil before server is started.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addr
+}
+
+// ShutdownTimeout returns configured shutdown timeout or its default.
+func (s *Server) ShutdownTimeout() time.Duration {
+	return s.shutdown
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests within shutdown timeout.
+func (s *Server) Shutdown(ctx context.Context) error {
+	const op = "serverApp.Shutdown"
+
+	log := s.log.With(slog.String("op", op))
+
+	ctx, cancel := context.WithTimeout(ctx, s.shutdown)
+	defer cancel()
+
+	log.Info("shutting down server", slog.Duration("timeout", s.shutdown))
+
+	if err := s.srv.Shutdown(ctx); err != nil {
+		log.Error("failed to drain connections", slog.Any("error", err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("server has been shut down")
+
+	return nil
+}
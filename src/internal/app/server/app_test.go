@@ -1,16 +1,96 @@
 package serverapp
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 	"io"
 	"log/slog"
+	"net"
+	"net/http"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
 	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
-	app := New(log, 1000, gin.New())
+	app := New(log, 1000, gin.New(), Timeouts{})
 
 	require.NotEmpty(t, app)
+	require.Equal(t, defaultReadTimeout, app.srv.ReadTimeout)
+	require.Equal(t, defaultShutdownTimeout, app.ShutdownTimeout())
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+
+	started := make(chan struct{})
+	router := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	app := New(log, 0, router, Timeouts{})
+
+	served := make(chan error, 1)
+	go func() { served <- app.Serve() }()
+	require.Eventually(t, func() bool { return app.Addr() != nil }, time.Second, time.Millisecond)
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + app.Addr().String()) //nolint:noctx // test request
+		if err != nil {
+			status <- 0
+			return
+		}
+		_ = resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+
+	// in-flight request is completed before shutdown returns
+	require.NoError(t, app.Shutdown(context.Background()))
+	require.Equal(t, http.StatusOK, <-status)
+	require.NoError(t, <-served)
+}
+
+func TestServer_Shutdown_Timeout(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	defer close(release)
+
+	app := New(log, 0, router, Timeouts{Shutdown: 10 * time.Millisecond})
+
+	go func() { _ = app.Serve() }()
+	require.Eventually(t, func() bool { return app.Addr() != nil }, time.Second, time.Millisecond)
+
+	go func() {
+		resp, err := http.Get("http://" + app.Addr().String()) //nolint:noctx // test request
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	<-started
+
+	require.ErrorIs(t, app.Shutdown(context.Background()), context.DeadlineExceeded)
+}
+
+func TestServer_Serve_BusyPort(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+
+	app := New(log, 0, gin.New(), Timeouts{})
+	go func() { _ = app.Serve() }()
+	require.Eventually(t, func() bool { return app.Addr() != nil }, time.Second, time.Millisecond)
+	defer func() { _ = app.Shutdown(context.Background()) }()
+
+	port := app.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert // tcp listener
+	require.ErrorIs(t, New(log, port, gin.New(), Timeouts{}).Serve(), errServerStopped)
 }
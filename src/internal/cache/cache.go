@@ -1,14 +1,39 @@
 // Package cache contains errors and types common to every cache provider.
 package cache
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // ErrKeyNotExists represents error when key is not present in cache.
 var ErrKeyNotExists = errors.New("key is not found")
 
 // Entry describes data stored in cache.
+// Zero time means that it is unknown or, for ExpiresAt, that entry never expires.
 type Entry struct {
-	Key string
-	Val []byte
-	ID  int64
+	Key        string
+	Val        []byte
+	ID         int64
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	Hits       int64     // Hits counts reads of entry since it was set.
+	LastAccess time.Time // LastAccess is time of the last read, zero when entry wasn't read.
+}
+
+// Stats describes cache size and removed entries.
+type Stats struct {
+	Entries     int
+	Bytes       int64
+	Evictions   int64 // Evictions counts entries removed to satisfy size limits.
+	Expirations int64 // Expirations counts expired entries removed.
+}
+
+// UnixTime converts unix time in seconds to time, zero seconds mean zero time.
+func UnixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(sec, 0)
 }
@@ -0,0 +1,428 @@
+// Package file provides cache implementation persisting entries to append-only log file, so cache survives restarts.
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	cachepkg "mortgage-calculator/src/internal/cache"
+	"mortgage-calculator/src/internal/logger"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const filePerm = 0o600
+
+// Cache stores cached data in memory and appends every change to log file.
+// Log is loaded on start and compacted by Clear.
+type Cache struct {
+	log    *slog.Logger
+	path   string
+	file   *os.File
+	data   map[string]record
+	byID   map[int64]string  // byID maps entry ids to keys
+	reads  map[string]access // reads are kept in memory only, so they are reset on restart
+	ttl    int64
+	lastID int64
+	stale  int // stale counts records of log file that are overwritten or expired
+	mu     sync.RWMutex
+}
+
+// access counts reads of entry.
+type access struct {
+	hits   int64
+	readAt int64
+}
+
+// record is a line of log file.
+// Record without key stores only last id, so ids keep growing when all entries are compacted.
+// Record without expiration time is a tombstone of deleted entry.
+type record struct {
+	Key     string `json:"key,omitempty"`
+	Val     []byte `json:"val,omitempty"`
+	ID      int64  `json:"id,omitempty"`
+	Exp     int64  `json:"exp,omitempty"`
+	Created int64  `json:"created,omitempty"`
+	LastID  int64  `json:"last_id,omitempty"`
+}
+
+// New is a constructor for Cache.
+// It loads active entries from file at path, file is created when it doesn't exist.
+func New(log *slog.Logger, ttl int64, path string) (*Cache, error) {
+	const op = "file.New"
+
+	c := &Cache{
+		log:   log,
+		path:  path,
+		ttl:   ttl,
+		data:  make(map[string]record),
+		byID:  make(map[int64]string),
+		reads: make(map[string]access),
+	}
+
+	if err := c.load(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, filePerm)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.file = file
+
+	log.Info("cache loaded", slog.String("path", path), slog.Int("entries", len(c.data)), slog.Int64("last_id", c.lastID))
+
+	return c, nil
+}
+
+// Clear deletes expired entries, rewrites log file without stale records and returns number of deleted entries.
+func (c *Cache) Clear(ctx context.Context) int {
+	const op = "file.Cache.Clear"
+	log := logger.FromContext(ctx, c.log).With(slog.String("op", op))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var res int
+	now := time.Now().Unix()
+	for _, item := range c.data {
+		if now > item.Exp {
+			c.remove(item)
+			c.stale++
+			res++
+		}
+	}
+
+	if c.stale == 0 {
+		return res
+	}
+
+	if err := c.compact(); err != nil {
+		log.Error("failed to compact cache file", slog.Any("error", err))
+		return res
+	}
+
+	log.Debug("cache file compacted", slog.Int("entries", len(c.data)))
+
+	return res
+}
+
+// Get returns value by key if latter exists else ErrKeyNotExists.
+func (c *Cache) Get(
+	_ context.Context,
+	key string,
+) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().Unix()
+	item, ok := c.data[key]
+	if !ok || now > item.Exp {
+		return nil, cachepkg.ErrKeyNotExists
+	}
+
+	a := c.reads[key]
+	c.reads[key] = access{hits: a.hits + 1, readAt: now}
+
+	return item.Val, nil
+}
+
+// Set saves given value by given key, sets expiration time and appends entry to log file.
+func (c *Cache) Set(
+	_ context.Context,
+	key string,
+	value []byte,
+) error {
+	const op = "file.Cache.Set"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().Unix()
+	item := record{
+		Key:     key,
+		Val:     value,
+		ID:      c.lastID + 1,
+		Exp:     now + c.ttl,
+		Created: now,
+	}
+
+	if err := c.append(item); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if old, ok := c.data[key]; ok {
+		c.remove(old)
+		c.stale++
+	}
+
+	c.lastID = item.ID
+	c.add(item)
+
+	return nil
+}
+
+// GetByID returns entry by id if latter exists else ErrKeyNotExists.
+func (c *Cache) GetByID(_ context.Context, id int64) (*cachepkg.Entry, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key, ok := c.byID[id]
+	if !ok || time.Now().Unix() > c.data[key].Exp {
+		return nil, cachepkg.ErrKeyNotExists
+	}
+
+	return c.entry(c.data[key]), nil
+}
+
+// Delete deletes entry by id if latter exists else returns ErrKeyNotExists.
+// Deletion is appended to log file as a tombstone.
+func (c *Cache) Delete(_ context.Context, id int64) error {
+	const op = "file.Cache.Delete"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, ok := c.byID[id]
+	if !ok {
+		return cachepkg.ErrKeyNotExists
+	}
+
+	if err := c.append(record{Key: key}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.remove(c.data[key])
+	c.stale += 2
+
+	return nil
+}
+
+// Purge deletes all entries, truncates log file and returns number of deleted entries.
+func (c *Cache) Purge(_ context.Context) (int, error) {
+	const op = "file.Cache.Purge"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// entries are dropped only after log file is rewritten, so they are kept on failure
+	if err := c.rewrite(nil); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := len(c.data)
+
+	c.data = make(map[string]record)
+	c.byID = make(map[int64]string)
+	c.reads = make(map[string]access)
+
+	return res, nil
+}
+
+// List returns all active cache entries.
+func (c *Cache) List(_ context.Context) ([]*cachepkg.Entry, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now().Unix()
+	res := make([]*cachepkg.Entry, 0, len(c.data))
+	for _, v := range c.data {
+		if now > v.Exp {
+			continue
+		}
+
+		res = append(res, c.entry(v))
+	}
+
+	return res, nil
+}
+
+// Ping checks that log file is still open and exists on disk.
+func (c *Cache) Ping(_ context.Context) error {
+	const op = "file.Cache.Ping"
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, err := c.file.Stat(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := os.Stat(c.path); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Close flushes log file to disk and closes it.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.file.Sync(); err != nil {
+		_ = c.file.Close()
+		return fmt.Errorf("file.Cache.Close: %w", err)
+	}
+
+	return c.file.Close() //nolint:wrapcheck // nothing to add
+}
+
+// load reads log file, the latest record of a key wins.
+// Incomplete last line left by interrupted write is truncated,
+// malformed records are skipped and removed by the next compaction.
+func (c *Cache) load() error {
+	file, err := os.Open(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err //nolint:wrapcheck // wrapped by caller
+	}
+	defer file.Close()
+
+	now := time.Now().Unix()
+	r := bufio.NewReader(file)
+	var size int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				c.log.Warn("incomplete cache record truncated", slog.String("path", c.path))
+				return os.Truncate(c.path, size) //nolint:wrapcheck // wrapped by caller
+			}
+			return nil
+		}
+		if err != nil {
+			return err //nolint:wrapcheck // wrapped by caller
+		}
+
+		size += int64(len(line))
+
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			c.log.Warn("malformed cache record skipped", slog.String("path", c.path), slog.Any("error", err))
+			c.stale++
+			continue
+		}
+
+		c.apply(rec, now)
+	}
+}
+
+// apply adds loaded record to cache.
+func (c *Cache) apply(rec record, now int64) {
+	c.lastID = max(c.lastID, rec.ID, rec.LastID)
+
+	if rec.Key == "" {
+		return
+	}
+
+	if old, ok := c.data[rec.Key]; ok {
+		c.remove(old)
+		c.stale++
+	}
+
+	if now > rec.Exp {
+		c.stale++
+		return
+	}
+
+	c.add(rec)
+}
+
+func (c *Cache) add(rec record) {
+	c.data[rec.Key] = rec
+	c.byID[rec.ID] = rec.Key
+}
+
+func (c *Cache) remove(rec record) {
+	delete(c.data, rec.Key)
+	delete(c.byID, rec.ID)
+	delete(c.reads, rec.Key)
+}
+
+// entry converts record to entry.
+func (c *Cache) entry(rec record) *cachepkg.Entry {
+	a := c.reads[rec.Key]
+
+	return &cachepkg.Entry{
+		ID:         rec.ID,
+		Key:        rec.Key,
+		Val:        rec.Val,
+		CreatedAt:  cachepkg.UnixTime(rec.Created),
+		ExpiresAt:  cachepkg.UnixTime(rec.Exp),
+		Hits:       a.hits,
+		LastAccess: cachepkg.UnixTime(a.readAt),
+	}
+}
+
+func (c *Cache) append(rec record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err //nolint:wrapcheck // wrapped by caller
+	}
+
+	_, err = c.file.Write(append(line, '\n'))
+
+	return err //nolint:wrapcheck // wrapped by caller
+}
+
+// compact writes active entries to temporary file and replaces log file with it.
+func (c *Cache) compact() error {
+	return c.rewrite(c.data)
+}
+
+// rewrite writes given records to temporary file and replaces log file with it.
+func (c *Cache) rewrite(data map[string]record) error {
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err //nolint:wrapcheck // wrapped by caller
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+
+	err = enc.Encode(record{LastID: c.lastID})
+	for _, rec := range data {
+		if err != nil {
+			break
+		}
+		err = enc.Encode(rec)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err //nolint:wrapcheck // wrapped by caller
+	}
+
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return err //nolint:wrapcheck // wrapped by caller
+	}
+
+	file, err := os.OpenFile(c.path, os.O_WRONLY|os.O_APPEND, filePerm)
+	if err != nil {
+		return err //nolint:wrapcheck // wrapped by caller
+	}
+
+	_ = c.file.Close()
+	c.file = file
+	c.stale = 0
+
+	return nil
+}
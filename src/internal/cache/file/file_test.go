@@ -0,0 +1,313 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	cachepkg "mortgage-calculator/src/internal/cache"
+	"mortgage-calculator/src/internal/lib/random"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setup(t *testing.T, ttl int64) (*Cache, string, context.Context) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "cache.log")
+	c := open(t, ttl, path)
+
+	return c, path, context.Background()
+}
+
+func open(t *testing.T, ttl int64, path string) *Cache {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	c, err := New(log, ttl, path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func lines(t *testing.T, path string) int {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	return bytes.Count(data, []byte("\n"))
+}
+
+func TestCache_Get_NonexistentKey(t *testing.T) {
+	c, _, ctx := setup(t, 100)
+
+	key, _ := random.String(10)
+	val, err := c.Get(ctx, key)
+	require.ErrorIs(t, err, cachepkg.ErrKeyNotExists)
+	require.Empty(t, val)
+}
+
+func TestCache_Set(t *testing.T) {
+	c, _, ctx := setup(t, 100)
+
+	key, _ := random.String(10)
+	val, _ := random.String(10)
+
+	require.NoError(t, c.Set(ctx, key, []byte(val)))
+
+	res, err := c.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, val, string(res))
+}
+
+func TestCache_Reload(t *testing.T) {
+	c, path, ctx := setup(t, 100)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "b", []byte("2")))
+	require.NoError(t, c.Set(ctx, "a", []byte("3")))
+	require.NoError(t, c.Close())
+
+	c = open(t, 100, path)
+
+	res, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, "3", string(res))
+
+	list, err := c.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+
+	// creation time is persisted, reads are counted since restart
+	entry, err := c.GetByID(ctx, 3)
+	require.NoError(t, err)
+	require.False(t, entry.CreatedAt.IsZero())
+	require.Equal(t, int64(1), entry.Hits)
+
+	// ids continue after restart
+	require.NoError(t, c.Set(ctx, "c", []byte("4")))
+	require.Equal(t, int64(4), c.lastID)
+}
+
+func TestCache_Reload_Expired(t *testing.T) {
+	c, path, ctx := setup(t, 0)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Close())
+
+	time.Sleep(1100 * time.Millisecond)
+
+	c = open(t, 0, path)
+
+	_, err := c.Get(ctx, "a")
+	require.ErrorIs(t, err, cachepkg.ErrKeyNotExists)
+	require.Equal(t, int64(1), c.lastID)
+}
+
+func TestCache_Reload_IncompleteRecord(t *testing.T) {
+	c, path, ctx := setup(t, 100)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Close())
+
+	// write interrupted by crash
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, filePerm)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"key":"b","va`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	c = open(t, 100, path)
+	require.NoError(t, c.Set(ctx, "c", []byte("2")))
+	require.NoError(t, c.Close())
+
+	c = open(t, 100, path)
+
+	list, err := c.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+}
+
+func TestCache_Reload_MalformedRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.log")
+	exp := time.Now().Add(time.Hour).Unix()
+	data := fmt.Sprintf("not json\n{\"key\":\"a\",\"val\":\"MQ==\",\"id\":1,\"exp\":%d,\"created\":%d}\n", exp, exp)
+	require.NoError(t, os.WriteFile(path, []byte(data), filePerm))
+
+	c := open(t, 100, path)
+	ctx := context.Background()
+
+	res, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), res)
+	require.Equal(t, 1, c.stale)
+
+	// malformed record is removed by compaction
+	c.Clear(ctx)
+	require.Equal(t, 2, lines(t, path))
+	require.NoError(t, c.Close())
+
+	c = open(t, 100, path)
+	require.Zero(t, c.stale)
+}
+
+func TestCache_Clear(t *testing.T) {
+	c, path, ctx := setup(t, 0)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "b", []byte("2")))
+
+	time.Sleep(1100 * time.Millisecond)
+
+	require.Equal(t, 2, c.Clear(ctx))
+
+	require.Empty(t, c.data)
+	// only last id is kept
+	require.Equal(t, 1, lines(t, path))
+
+	require.NoError(t, c.Set(ctx, "c", []byte("3")))
+	require.Equal(t, 2, lines(t, path))
+	require.NoError(t, c.Close())
+
+	c = open(t, 100, path)
+	require.Equal(t, int64(3), c.lastID)
+}
+
+func TestCache_Clear_Overwritten(t *testing.T) {
+	c, path, ctx := setup(t, 100)
+
+	for range 3 {
+		require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	}
+	require.Equal(t, 3, lines(t, path))
+
+	c.Clear(ctx)
+
+	require.Equal(t, 2, lines(t, path))
+
+	res, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, "1", string(res))
+}
+
+func TestCache_GetByID(t *testing.T) {
+	c, _, ctx := setup(t, 100)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "a", []byte("2")))
+
+	_, err := c.GetByID(ctx, 1)
+	require.ErrorIs(t, err, cachepkg.ErrKeyNotExists)
+
+	res, err := c.GetByID(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), res.ID)
+	require.Equal(t, "a", res.Key)
+	require.Equal(t, "2", string(res.Val))
+}
+
+func TestCache_Delete(t *testing.T) {
+	c, path, ctx := setup(t, 100)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "b", []byte("2")))
+
+	require.NoError(t, c.Delete(ctx, 1))
+	require.ErrorIs(t, c.Delete(ctx, 1), cachepkg.ErrKeyNotExists)
+	require.NoError(t, c.Close())
+
+	// deletion survives restart
+	c = open(t, 100, path)
+
+	_, err := c.Get(ctx, "a")
+	require.ErrorIs(t, err, cachepkg.ErrKeyNotExists)
+
+	res, err := c.GetByID(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, "b", res.Key)
+}
+
+func TestCache_Purge(t *testing.T) {
+	c, path, ctx := setup(t, 100)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "b", []byte("2")))
+
+	n, err := c.Purge(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, 1, lines(t, path))
+	require.NoError(t, c.Close())
+
+	c = open(t, 100, path)
+
+	list, err := c.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, list)
+	require.Equal(t, int64(2), c.lastID)
+}
+
+func TestCache_Purge_RewriteFailed(t *testing.T) {
+	c, path, ctx := setup(t, 100)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+
+	// log file can't be replaced by non-empty directory
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, os.MkdirAll(filepath.Join(path, "dir"), 0o700))
+
+	_, err := c.Purge(ctx)
+	require.Error(t, err)
+
+	res, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), res)
+}
+
+func TestCache_Metadata(t *testing.T) {
+	c, _, ctx := setup(t, 100)
+
+	before := time.Now().Truncate(time.Second)
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+
+	res, err := c.GetByID(ctx, 1)
+	require.NoError(t, err)
+	require.False(t, res.CreatedAt.Before(before))
+	require.Equal(t, res.CreatedAt.Add(100*time.Second), res.ExpiresAt)
+	require.Zero(t, res.Hits)
+	require.True(t, res.LastAccess.IsZero())
+
+	for range 2 {
+		_, err = c.Get(ctx, "a")
+		require.NoError(t, err)
+	}
+
+	list, err := c.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, int64(2), list[0].Hits)
+	require.False(t, list[0].LastAccess.Before(res.CreatedAt))
+
+	// overwritten entry is counted anew
+	require.NoError(t, c.Set(ctx, "a", []byte("2")))
+
+	res, err = c.GetByID(ctx, 2)
+	require.NoError(t, err)
+	require.Zero(t, res.Hits)
+	require.True(t, res.LastAccess.IsZero())
+}
+
+func TestCache_Ping(t *testing.T) {
+	c, path, ctx := setup(t, 100)
+
+	require.NoError(t, c.Ping(ctx))
+
+	require.NoError(t, os.Remove(path))
+	require.Error(t, c.Ping(ctx))
+}
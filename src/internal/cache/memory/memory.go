@@ -2,52 +2,116 @@
 package memory
 
 import (
+	"container/heap"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	cachepkg "mortgage-calculator/src/internal/cache"
+	"mortgage-calculator/src/internal/lib/clock"
 	"sync"
-	"time"
 )
 
+// ErrEntryTooLarge represents error when single entry exceeds cache size limit.
+var ErrEntryTooLarge = errors.New("entry exceeds cache size limit")
+
+// ErrUnknownPolicy represents error when eviction policy is not supported.
+var ErrUnknownPolicy = errors.New("unknown eviction policy")
+
+// Policy defines which entry is evicted when cache is full.
+type Policy string
+
+// Eviction policies.
+const (
+	PolicyLRU Policy = "lru" // PolicyLRU evicts least recently used entry.
+	PolicyLFU Policy = "lfu" // PolicyLFU evicts least frequently used entry, the least recently used one among equals.
+)
+
+// ParsePolicy converts policy name to Policy, empty name means LRU.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case "":
+		return PolicyLRU, nil
+	case PolicyLRU, PolicyLFU:
+		return p, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownPolicy, s)
+	}
+}
+
+// Limits bound cache size, zero value of any limit means that the limit is not applied.
+type Limits struct {
+	MaxEntries int
+	MaxBytes   int64 // MaxBytes limits total size of keys and values.
+	Policy     Policy
+}
+
 // Cache stores cached data.
 type Cache struct {
 	log    *slog.Logger
+	clk    clock.Clock
 	data   cache
+	byID   map[int64]*cacheItem
+	queue  evictionQueue
+	limits Limits
 	ttl    int64
 	lastID int64
-	mu     sync.RWMutex
+	tick   int64 // tick orders accesses to entries
+	stats  cachepkg.Stats
+	mu     sync.Mutex
 }
 
-type cache map[string]cacheItem
+type cache map[string]*cacheItem
 
 type cacheItem struct {
-	val []byte
-	id  int64
-	exp int64
+	key      string
+	val      []byte
+	id       int64
+	created  int64
+	exp      int64
+	reads    int64 // reads counts Get hits, unlike hits it doesn't count setting of entry
+	readAt   int64
+	hits     int64
+	lastUsed int64
+	index    int // index in eviction queue
 }
 
 // New is a constructor for Cache.
-func New(log *slog.Logger, ttl int64) *Cache {
-	data := make(cache)
+// Empty policy of limits means LRU, expiration of entries is checked by given clock.
+func New(log *slog.Logger, ttl int64, limits Limits, clk clock.Clock) *Cache {
+	if limits.Policy == "" {
+		limits.Policy = PolicyLRU
+	}
 
-	return &Cache{
-		log:  log,
-		ttl:  ttl,
-		data: data,
+	c := &Cache{
+		log:    log,
+		clk:    clk,
+		ttl:    ttl,
+		data:   make(cache),
+		byID:   make(map[int64]*cacheItem),
+		limits: limits,
 	}
+	c.queue.less = c.less
+
+	return c
 }
 
-// Clear checks whether entries are expired and deletes them if true.
-func (c *Cache) Clear(_ context.Context) {
+// Clear checks whether entries are expired, deletes them if true and returns number of deleted entries.
+func (c *Cache) Clear(_ context.Context) int {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	now := time.Now().Unix()
-	for key, item := range c.data {
+	var res int
+	now := c.clk.Now().Unix()
+	for _, item := range c.data {
 		if now > item.exp {
-			delete(c.data, key)
+			c.remove(item)
+			c.stats.Expirations++
+			res++
 		}
 	}
+
+	return res
 }
 
 // Get returns value by key if latter exists else ErrKeyNotExists.
@@ -55,51 +119,227 @@ func (c *Cache) Get(
 	_ context.Context,
 	key string,
 ) ([]byte, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	item, ok := c.data[key]
+	if !ok {
+		return nil, cachepkg.ErrKeyNotExists
+	}
+
+	now := c.clk.Now().Unix()
+	if now > item.exp {
+		c.remove(item)
+		c.stats.Expirations++
 
-	if !ok || time.Now().Unix() > item.exp {
-		delete(c.data, key)
 		return nil, cachepkg.ErrKeyNotExists
 	}
 
+	c.touch(item)
+	item.reads++
+	item.readAt = now
+
 	return item.val, nil
 }
 
 // Set saves given value by given key and sets expiration time.
+// Entries are evicted according to policy when cache exceeds limits.
 func (c *Cache) Set(
 	_ context.Context,
 	key string,
 	value []byte,
 ) error {
+	if c.limits.MaxBytes > 0 && size(key, value) > c.limits.MaxBytes {
+		return fmt.Errorf("memory.Cache.Set: %w", ErrEntryTooLarge)
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if old, ok := c.data[key]; ok {
+		c.remove(old)
+	}
+
+	// new entry is never evicted, otherwise LFU would evict it at once
+	c.evict(size(key, value))
+
 	c.lastID++
-	c.data[key] = cacheItem{
-		id:  c.lastID,
-		val: value,
-		exp: time.Now().Unix() + c.ttl,
+	now := c.clk.Now().Unix()
+	item := &cacheItem{
+		key:     key,
+		id:      c.lastID,
+		val:     value,
+		created: now,
+		exp:     now + c.ttl,
 	}
 
+	c.data[key] = item
+	c.byID[item.id] = item
+	c.stats.Entries++
+	c.stats.Bytes += size(key, value)
+	heap.Push(&c.queue, item)
+	c.touch(item)
+
 	return nil
 }
 
+// GetByID returns entry by id if latter exists else ErrKeyNotExists.
+func (c *Cache) GetByID(_ context.Context, id int64) (*cachepkg.Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.byID[id]
+	if !ok || c.clk.Now().Unix() > item.exp {
+		return nil, cachepkg.ErrKeyNotExists
+	}
+
+	return item.entry(), nil
+}
+
+// Delete deletes entry by id if latter exists else returns ErrKeyNotExists.
+func (c *Cache) Delete(_ context.Context, id int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.byID[id]
+	if !ok {
+		return cachepkg.ErrKeyNotExists
+	}
+
+	c.remove(item)
+
+	return nil
+}
+
+// Purge deletes all entries and returns their number.
+func (c *Cache) Purge(_ context.Context) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := len(c.data)
+
+	c.data = make(cache)
+	c.byID = make(map[int64]*cacheItem)
+	c.queue.items = nil
+	c.stats.Entries = 0
+	c.stats.Bytes = 0
+
+	return res, nil
+}
+
 // List returns all active cache entries.
 func (c *Cache) List(_ context.Context) ([]*cachepkg.Entry, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	res := make([]*cachepkg.Entry, 0)
-	for k, v := range c.data {
-		res = append(res, &cachepkg.Entry{
-			ID:  v.id,
-			Key: k,
-			Val: v.val,
-		})
+	res := make([]*cachepkg.Entry, 0, len(c.data))
+	for _, v := range c.data {
+		res = append(res, v.entry())
 	}
 
 	return res, nil
 }
+
+// Stats returns current cache size and counters of removed entries.
+func (c *Cache) Stats(_ context.Context) cachepkg.Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
+
+// evict removes entries by policy until new entry of given size fits limits.
+func (c *Cache) evict(newSize int64) {
+	for c.exceeded(newSize) {
+		item, ok := heap.Pop(&c.queue).(*cacheItem)
+		if !ok {
+			return
+		}
+
+		c.remove(item)
+		c.stats.Evictions++
+
+		c.log.Debug("cache entry evicted", slog.Int64("id", item.id), slog.String("policy", string(c.limits.Policy)))
+	}
+}
+
+func (c *Cache) exceeded(newSize int64) bool {
+	return c.limits.MaxEntries > 0 && c.stats.Entries+1 > c.limits.MaxEntries ||
+		c.limits.MaxBytes > 0 && c.stats.Bytes+newSize > c.limits.MaxBytes
+}
+
+func (c *Cache) remove(item *cacheItem) {
+	if item.index >= 0 {
+		heap.Remove(&c.queue, item.index)
+	}
+
+	delete(c.data, item.key)
+	delete(c.byID, item.id)
+	c.stats.Entries--
+	c.stats.Bytes -= size(item.key, item.val)
+}
+
+// touch registers access to entry.
+func (c *Cache) touch(item *cacheItem) {
+	c.tick++
+	item.hits++
+	item.lastUsed = c.tick
+	heap.Fix(&c.queue, item.index)
+}
+
+// less reports whether entry a should be evicted before entry b.
+func (c *Cache) less(a, b *cacheItem) bool {
+	if c.limits.Policy == PolicyLFU && a.hits != b.hits {
+		return a.hits < b.hits
+	}
+
+	return a.lastUsed < b.lastUsed
+}
+
+func (item *cacheItem) entry() *cachepkg.Entry {
+	return &cachepkg.Entry{
+		ID:         item.id,
+		Key:        item.key,
+		Val:        item.val,
+		CreatedAt:  cachepkg.UnixTime(item.created),
+		ExpiresAt:  cachepkg.UnixTime(item.exp),
+		Hits:       item.reads,
+		LastAccess: cachepkg.UnixTime(item.readAt),
+	}
+}
+
+func size(key string, value []byte) int64 {
+	return int64(len(key) + len(value))
+}
+
+// evictionQueue is a heap of entries, the first one is evicted first.
+type evictionQueue struct {
+	items []*cacheItem
+	less  func(a, b *cacheItem) bool
+}
+
+func (q *evictionQueue) Len() int { return len(q.items) }
+
+func (q *evictionQueue) Less(i, j int) bool { return q.less(q.items[i], q.items[j]) }
+
+func (q *evictionQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.items[i].index = i
+	q.items[j].index = j
+}
+
+func (q *evictionQueue) Push(x any) {
+	item, _ := x.(*cacheItem)
+	item.index = len(q.items)
+	q.items = append(q.items, item)
+}
+
+func (q *evictionQueue) Pop() any {
+	n := len(q.items)
+	item := q.items[n-1]
+	q.items[n-1] = nil
+	q.items = q.items[:n-1]
+	item.index = -1
+
+	return item
+}
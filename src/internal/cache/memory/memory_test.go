@@ -11,16 +11,32 @@ import (
 	"time"
 )
 
-func setup(ttl int64) (*Cache, context.Context) {
+var startedAt = time.Date(2024, 6, 18, 15, 4, 5, 0, time.UTC)
+
+// movingClock is a clock which is moved forward by tests.
+type movingClock struct {
+	now time.Time
+}
+
+func (c *movingClock) Now() time.Time {
+	return c.now
+}
+
+func (c *movingClock) add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func setup(ttl int64) (*Cache, *movingClock, context.Context) {
 	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
-	c := New(log, ttl)
+	clk := &movingClock{now: startedAt}
+	c := New(log, ttl, Limits{}, clk)
 	ctx := context.Background()
 
-	return c, ctx
+	return c, clk, ctx
 }
 
 func TestNew(t *testing.T) {
-	c, _ := setup(100)
+	c, _, _ := setup(100)
 
 	if c == nil {
 		t.Fatalf("calculator service is nil")
@@ -28,7 +44,7 @@ func TestNew(t *testing.T) {
 }
 
 func TestCache_Get_NonexistentKey(t *testing.T) {
-	c, ctx := setup(100)
+	c, _, ctx := setup(100)
 
 	key, _ := random.String(10)
 	val, err := c.Get(ctx, key)
@@ -38,7 +54,7 @@ func TestCache_Get_NonexistentKey(t *testing.T) {
 }
 
 func TestCache_Set(t *testing.T) {
-	c, ctx := setup(100)
+	c, _, ctx := setup(100)
 
 	key, _ := random.String(10)
 	val, _ := random.String(10)
@@ -53,7 +69,7 @@ func TestCache_Set(t *testing.T) {
 }
 
 func TestCache_Get(t *testing.T) {
-	c, ctx := setup(100)
+	c, _, ctx := setup(100)
 
 	key, _ := random.String(10)
 	val, _ := random.String(10)
@@ -68,7 +84,7 @@ func TestCache_Get(t *testing.T) {
 }
 
 func TestCache_List_EmptyCache(t *testing.T) {
-	c, ctx := setup(100)
+	c, _, ctx := setup(100)
 
 	list, err := c.List(ctx)
 	require.NoError(t, err)
@@ -76,7 +92,7 @@ func TestCache_List_EmptyCache(t *testing.T) {
 }
 
 func TestCache_List(t *testing.T) {
-	c, ctx := setup(100)
+	c, _, ctx := setup(100)
 
 	key, _ := random.String(10)
 
@@ -92,16 +108,215 @@ func TestCache_List(t *testing.T) {
 }
 
 func TestCache_Clear(t *testing.T) {
-	c, ctx := setup(0)
+	c, clk, ctx := setup(0)
 
 	key, _ := random.String(10)
 
 	err := c.Set(ctx, key, []byte(key))
 	require.Empty(t, err)
 
-	time.Sleep(1 * time.Second)
+	clk.add(time.Second)
 
 	c.Clear(ctx)
 
 	require.Empty(t, c.data)
 }
+
+func setupBounded(limits Limits) (*Cache, context.Context) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+
+	return New(log, 100, limits, &movingClock{now: startedAt}), context.Background()
+}
+
+func requireKeys(t *testing.T, c *Cache, keys ...string) {
+	t.Helper()
+
+	list, err := c.List(context.Background())
+	require.NoError(t, err)
+
+	res := make([]string, len(list))
+	for i, e := range list {
+		res[i] = e.Key
+	}
+
+	require.ElementsMatch(t, keys, res)
+}
+
+func TestCache_Set_EvictLRU(t *testing.T) {
+	c, ctx := setupBounded(Limits{MaxEntries: 2})
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "b", []byte("2")))
+
+	_, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "c", []byte("3")))
+
+	requireKeys(t, c, "a", "c")
+	require.Equal(t, cachepkg.Stats{Entries: 2, Bytes: 4, Evictions: 1}, c.Stats(ctx))
+}
+
+func TestCache_Set_EvictLFU(t *testing.T) {
+	c, ctx := setupBounded(Limits{MaxEntries: 2, Policy: PolicyLFU})
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "b", []byte("2")))
+
+	for range 2 {
+		_, err := c.Get(ctx, "a")
+		require.NoError(t, err)
+	}
+	_, err := c.Get(ctx, "b")
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "c", []byte("3")))
+	requireKeys(t, c, "a", "c")
+
+	// new entry is the least frequently used one
+	require.NoError(t, c.Set(ctx, "d", []byte("4")))
+	requireKeys(t, c, "a", "d")
+	require.Equal(t, int64(2), c.Stats(ctx).Evictions)
+}
+
+func TestCache_Set_MaxBytes(t *testing.T) {
+	c, ctx := setupBounded(Limits{MaxBytes: 10})
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1234")))
+	require.NoError(t, c.Set(ctx, "b", []byte("1234")))
+	require.NoError(t, c.Set(ctx, "c", []byte("1234")))
+
+	requireKeys(t, c, "b", "c")
+	require.Equal(t, int64(10), c.Stats(ctx).Bytes)
+
+	err := c.Set(ctx, "d", []byte("1234567890"))
+	require.ErrorIs(t, err, ErrEntryTooLarge)
+	requireKeys(t, c, "b", "c")
+}
+
+func TestCache_Set_Overwrite(t *testing.T) {
+	c, ctx := setupBounded(Limits{MaxEntries: 2})
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "a", []byte("22")))
+
+	res, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, "22", string(res))
+	require.Equal(t, cachepkg.Stats{Entries: 1, Bytes: 3}, c.Stats(ctx))
+}
+
+func TestCache_Clear_Stats(t *testing.T) {
+	c, clk, ctx := setup(0)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "b", []byte("2")))
+
+	// entry is still active within the second it expires at
+	clk.add(time.Second - time.Nanosecond)
+	_, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+
+	clk.add(time.Nanosecond)
+
+	_, err = c.Get(ctx, "a")
+	require.ErrorIs(t, err, cachepkg.ErrKeyNotExists)
+
+	require.Equal(t, 1, c.Clear(ctx))
+
+	require.Equal(t, cachepkg.Stats{Expirations: 2}, c.Stats(ctx))
+	require.Empty(t, c.queue.items)
+}
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy("")
+	require.NoError(t, err)
+	require.Equal(t, PolicyLRU, p)
+
+	p, err = ParsePolicy("lfu")
+	require.NoError(t, err)
+	require.Equal(t, PolicyLFU, p)
+
+	_, err = ParsePolicy("fifo")
+	require.ErrorIs(t, err, ErrUnknownPolicy)
+}
+
+func TestCache_GetByID(t *testing.T) {
+	c, _, ctx := setup(100)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "a", []byte("2")))
+
+	_, err := c.GetByID(ctx, 1)
+	require.ErrorIs(t, err, cachepkg.ErrKeyNotExists)
+
+	res, err := c.GetByID(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), res.ID)
+	require.Equal(t, "a", res.Key)
+	require.Equal(t, "2", string(res.Val))
+}
+
+func TestCache_Delete(t *testing.T) {
+	c, _, ctx := setup(100)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "b", []byte("2")))
+
+	require.NoError(t, c.Delete(ctx, 1))
+	require.ErrorIs(t, c.Delete(ctx, 1), cachepkg.ErrKeyNotExists)
+
+	requireKeys(t, c, "b")
+	require.Equal(t, cachepkg.Stats{Entries: 1, Bytes: 2}, c.Stats(ctx))
+}
+
+func TestCache_Purge(t *testing.T) {
+	c, _, ctx := setup(100)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "b", []byte("2")))
+
+	n, err := c.Purge(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	requireKeys(t, c)
+
+	// ids keep growing
+	require.NoError(t, c.Set(ctx, "c", []byte("3")))
+	res, err := c.GetByID(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, "c", res.Key)
+}
+
+func TestCache_Metadata(t *testing.T) {
+	c, clk, ctx := setup(100)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+
+	res, err := c.GetByID(ctx, 1)
+	require.NoError(t, err)
+	require.True(t, res.CreatedAt.Equal(startedAt))
+	require.True(t, res.ExpiresAt.Equal(startedAt.Add(100*time.Second)))
+	require.Zero(t, res.Hits)
+	require.True(t, res.LastAccess.IsZero())
+
+	for range 2 {
+		clk.add(30 * time.Second)
+		_, err = c.Get(ctx, "a")
+		require.NoError(t, err)
+	}
+
+	list, err := c.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, int64(2), list[0].Hits)
+	require.True(t, list[0].LastAccess.Equal(startedAt.Add(time.Minute)))
+
+	// overwritten entry is counted anew
+	require.NoError(t, c.Set(ctx, "a", []byte("2")))
+
+	res, err = c.GetByID(ctx, 2)
+	require.NoError(t, err)
+	require.Zero(t, res.Hits)
+	require.True(t, res.LastAccess.IsZero())
+}
@@ -0,0 +1,653 @@
+// Package redis provides cache implementation storing entries in Redis server, so it is shared by all replicas.
+// Client speaks RESP protocol and doesn't depend on third-party libraries.
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	cachepkg "mortgage-calculator/src/internal/cache"
+	"mortgage-calculator/src/internal/logger"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options describe connection to Redis server.
+type Options struct {
+	Addr     string
+	Password string
+	DB       int
+	Prefix   string        // Prefix is prepended to every key, so several apps may share one database.
+	PoolSize int           // PoolSize limits number of idle connections.
+	Timeout  time.Duration // Timeout limits dialing and every command when context has no deadline.
+}
+
+const (
+	defaultPrefix   = "mortgage:"
+	defaultPoolSize = 10
+	defaultTimeout  = 3 * time.Second
+	scanCount       = "100"
+	batchSize       = 100
+	statsTTL        = 10 * time.Second
+)
+
+var errBadEntry = errors.New("malformed cache entry")
+
+// Cache stores cached data in Redis.
+// Expiration is handled by Redis natively.
+// Every entry has an index key mapping its id to entry key, index expires together with entry.
+// Reads of entry are counted in hits key and time of the last read is kept in seen key,
+// both are deleted when entry is overwritten or deleted and expire not earlier than entry.
+type Cache struct {
+	log  *slog.Logger
+	opts Options
+	ttl  int64
+	pool chan *conn
+
+	statsMu sync.Mutex
+	stats   cachepkg.Stats
+	statsAt time.Time
+}
+
+type conn struct {
+	nc net.Conn
+	r  *bufio.Reader
+	w  *bufio.Writer
+}
+
+// New is a constructor for Cache.
+// Connections are established lazily, so New doesn't fail when server is not available yet.
+// Non-positive ttl means that entries never expire.
+func New(log *slog.Logger, ttl int64, opts Options) *Cache {
+	if opts.Prefix == "" {
+		opts.Prefix = defaultPrefix
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = defaultPoolSize
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	return &Cache{
+		log:  log,
+		opts: opts,
+		ttl:  ttl,
+		pool: make(chan *conn, opts.PoolSize),
+	}
+}
+
+// Get returns value by key if latter exists else ErrKeyNotExists.
+// Read is counted by the same pipeline, so reading takes single round trip.
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	const op = "redis.Cache.Get"
+	log := logger.FromContext(ctx, c.log).With(slog.String("op", op))
+
+	replies, err := c.send(ctx, append([][]string{{"GET", c.entryKey(key)}}, c.readCommands(key)...)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// failure to count read doesn't fail reading
+	for _, reply := range replies[1:] {
+		if e, ok := reply.(Error); ok {
+			log.Warn("failed to count cache read", slog.Any("error", e))
+			break
+		}
+	}
+
+	switch reply := replies[0].(type) {
+	case Error:
+		return nil, fmt.Errorf("%s: %w", op, reply)
+	case nil:
+		// counters of missing entry are created by the pipeline, they are deleted to not outlive it
+		if _, err := c.do(ctx, "DEL", c.hitsKey(key), c.seenKey(key)); err != nil {
+			log.Warn("failed to delete read counters", slog.Any("error", err))
+		}
+
+		return nil, cachepkg.ErrKeyNotExists
+	}
+
+	e, err := c.decodeEntry(replies[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return e.Val, nil
+}
+
+// Set saves given value by given key and sets expiration time.
+func (c *Cache) Set(ctx context.Context, key string, value []byte) error {
+	const op = "redis.Cache.Set"
+
+	reply, err := c.do(ctx, "INCR", c.opts.Prefix+"id")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, ok := reply.(int64)
+	if !ok {
+		return fmt.Errorf("%s: %w: unexpected id reply", op, ErrProtocol)
+	}
+
+	_, err = c.pipeline(ctx,
+		c.withTTL("SET", c.entryKey(key), encodeEntry(id, time.Now().Unix(), value)),
+		c.withTTL("SET", c.idKey(id), key),
+		[]string{"DEL", c.hitsKey(key), c.seenKey(key)},
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetByID returns entry by id if latter exists else ErrKeyNotExists.
+func (c *Cache) GetByID(ctx context.Context, id int64) (*cachepkg.Entry, error) {
+	const op = "redis.Cache.GetByID"
+
+	res, err := c.entryByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// Delete deletes entry by id if latter exists else returns ErrKeyNotExists.
+func (c *Cache) Delete(ctx context.Context, id int64) error {
+	const op = "redis.Cache.Delete"
+
+	entry, err := c.entryByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := c.do(ctx, "DEL", c.entryKey(entry.Key), c.idKey(id), c.hitsKey(entry.Key), c.seenKey(entry.Key)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Purge deletes all entries and returns their number.
+// Id counter is kept, so ids keep growing.
+func (c *Cache) Purge(ctx context.Context) (int, error) {
+	const op = "redis.Cache.Purge"
+
+	entries, err := c.scan(ctx, escapePattern(c.opts.Prefix+"entry:")+"*")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := c.del(ctx, entries)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, kind := range []string{"id:", "hits:", "seen:"} {
+		keys, err := c.scan(ctx, escapePattern(c.opts.Prefix+kind)+"*")
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := c.del(ctx, keys); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return res, nil
+}
+
+// Clear does nothing because Redis deletes expired entries itself.
+func (c *Cache) Clear(_ context.Context) int {
+	c.log.Debug("redis expires entries natively, nothing to clear")
+
+	return 0
+}
+
+// List returns all active cache entries.
+func (c *Cache) List(ctx context.Context) ([]*cachepkg.Entry, error) {
+	const op = "redis.Cache.List"
+
+	keys, err := c.scan(ctx, escapePattern(c.opts.Prefix+"entry:")+"*")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := make([]*cachepkg.Entry, 0, len(keys))
+	for start := 0; start < len(keys); start += batchSize {
+		batch := keys[start:min(start+batchSize, len(keys))]
+
+		// values are followed by read counters and read times of the same entries
+		args := make([]string, 0, 1+3*len(batch))
+		args = append(args, "MGET")
+		args = append(args, batch...)
+		for _, k := range batch {
+			args = append(args, c.hitsKey(strings.TrimPrefix(k, c.opts.Prefix+"entry:")))
+		}
+		for _, k := range batch {
+			args = append(args, c.seenKey(strings.TrimPrefix(k, c.opts.Prefix+"entry:")))
+		}
+
+		reply, err := c.do(ctx, args...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		values, ok := reply.([]any)
+		if !ok || len(values) != 3*len(batch) {
+			return nil, fmt.Errorf("%s: %w: unexpected MGET reply", op, ErrProtocol)
+		}
+
+		for i, v := range values[:len(batch)] {
+			// entry has expired after scan
+			if v == nil {
+				continue
+			}
+
+			e, err := c.decodeEntry(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+
+			e.Key = strings.TrimPrefix(batch[i], c.opts.Prefix+"entry:")
+			setReads(e, values[len(batch)+i], values[2*len(batch)+i])
+			res = append(res, e)
+		}
+	}
+
+	return res, nil
+}
+
+// Stats returns number of entries counted by scanning their keys without reading values.
+// Redis evicts and expires entries itself, so removed entries aren't counted.
+// Scanning walks whole keyspace, so result is reused for statsTTL.
+func (c *Cache) Stats(ctx context.Context) cachepkg.Stats {
+	const op = "redis.Cache.Stats"
+
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	if time.Since(c.statsAt) < statsTTL {
+		return c.stats
+	}
+
+	var entries int
+	err := c.each(ctx, escapePattern(c.opts.Prefix+"entry:")+"*", func(string) { entries++ })
+	if err != nil {
+		logger.FromContext(ctx, c.log).Warn("failed to count entries", slog.String("op", op), slog.Any("error", err))
+		return cachepkg.Stats{}
+	}
+
+	c.stats = cachepkg.Stats{Entries: entries}
+	c.statsAt = time.Now()
+
+	return c.stats
+}
+
+// Ping checks that server is available.
+func (c *Cache) Ping(ctx context.Context) error {
+	if _, err := c.do(ctx, "PING"); err != nil {
+		return fmt.Errorf("redis.Cache.Ping: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes idle connections.
+func (c *Cache) Close() error {
+	for {
+		select {
+		case cn := <-c.pool:
+			_ = cn.nc.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// entryByID finds entry by index key.
+// Index of overwritten entry points to entry with another id, so such entry is not found.
+func (c *Cache) entryByID(ctx context.Context, id int64) (*cachepkg.Entry, error) {
+	key, err := c.do(ctx, "GET", c.idKey(id))
+	if err != nil {
+		return nil, err
+	}
+
+	k, ok := key.([]byte)
+	if !ok {
+		return nil, cachepkg.ErrKeyNotExists
+	}
+
+	reply, err := c.do(ctx, "MGET", c.entryKey(string(k)), c.hitsKey(string(k)), c.seenKey(string(k)))
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := reply.([]any)
+	if !ok || len(values) != 3 {
+		return nil, fmt.Errorf("%w: unexpected MGET reply", ErrProtocol)
+	}
+	if values[0] == nil {
+		return nil, cachepkg.ErrKeyNotExists
+	}
+
+	res, err := c.decodeEntry(values[0])
+	if err != nil {
+		return nil, err
+	}
+	if res.ID != id {
+		return nil, cachepkg.ErrKeyNotExists
+	}
+
+	res.Key = string(k)
+	setReads(res, values[1], values[2])
+
+	return res, nil
+}
+
+// readCommands returns commands incrementing read counter of entry and storing time of the read.
+func (c *Cache) readCommands(key string) [][]string {
+	cmds := [][]string{{"INCR", c.hitsKey(key)}}
+	if c.ttl > 0 {
+		cmds = append(cmds, []string{"EXPIRE", c.hitsKey(key), strconv.FormatInt(c.ttl, 10)})
+	}
+
+	return append(cmds, c.withTTL("SET", c.seenKey(key), strconv.FormatInt(time.Now().Unix(), 10)))
+}
+
+// del deletes keys in batches and returns number of deleted keys.
+func (c *Cache) del(ctx context.Context, keys []string) (int, error) {
+	var res int
+	for start := 0; start < len(keys); start += batchSize {
+		reply, err := c.do(ctx, append([]string{"DEL"}, keys[start:min(start+batchSize, len(keys))]...)...)
+		if err != nil {
+			return 0, err
+		}
+
+		n, _ := reply.(int64)
+		res += int(n)
+	}
+
+	return res, nil
+}
+
+// scan collects all keys matching pattern.
+func (c *Cache) scan(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	if err := c.each(ctx, pattern, func(key string) { keys = append(keys, key) }); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// each calls fn for every key matching pattern.
+func (c *Cache) each(ctx context.Context, pattern string, fn func(key string)) error {
+	cursor := "0"
+	for {
+		reply, err := c.do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", scanCount)
+		if err != nil {
+			return err
+		}
+
+		parts, ok := reply.([]any)
+		if !ok || len(parts) != 2 {
+			return fmt.Errorf("%w: unexpected SCAN reply", ErrProtocol)
+		}
+
+		next, ok := parts[0].([]byte)
+		found, ok2 := parts[1].([]any)
+		if !ok || !ok2 {
+			return fmt.Errorf("%w: unexpected SCAN reply", ErrProtocol)
+		}
+
+		for _, k := range found {
+			if key, ok := k.([]byte); ok {
+				fn(string(key))
+			}
+		}
+
+		cursor = string(next)
+		if cursor == "0" {
+			return nil
+		}
+	}
+}
+
+// do sends command using pooled connection and returns its reply.
+func (c *Cache) do(ctx context.Context, args ...string) (any, error) {
+	replies, err := c.pipeline(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return replies[0], nil
+}
+
+// pipeline sends commands at once using pooled connection and returns their replies.
+// Error reply of any command is returned as error.
+func (c *Cache) pipeline(ctx context.Context, cmds ...[]string) ([]any, error) {
+	replies, err := c.send(ctx, cmds...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reply := range replies {
+		if e, ok := reply.(Error); ok {
+			return nil, e
+		}
+	}
+
+	return replies, nil
+}
+
+// send sends commands at once using pooled connection and returns their replies including error ones.
+func (c *Cache) send(ctx context.Context, cmds ...[]string) ([]any, error) {
+	cn, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := cn.pipeline(ctx, c.opts.Timeout, cmds...)
+	if err != nil {
+		// connection state is unknown after network error
+		_ = cn.nc.Close()
+		return nil, err
+	}
+
+	c.release(cn)
+
+	return replies, nil
+}
+
+// conn takes idle connection from pool or dials a new one.
+func (c *Cache) conn(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.pool:
+		return cn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: c.opts.Timeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	cn := &conn{
+		nc: nc,
+		r:  bufio.NewReader(nc),
+		w:  bufio.NewWriter(nc),
+	}
+
+	if err := c.handshake(ctx, cn); err != nil {
+		_ = nc.Close()
+		return nil, err
+	}
+
+	return cn, nil
+}
+
+// handshake authenticates connection and selects database.
+func (c *Cache) handshake(ctx context.Context, cn *conn) error {
+	var commands [][]string
+	if c.opts.Password != "" {
+		commands = append(commands, []string{"AUTH", c.opts.Password})
+	}
+	if c.opts.DB != 0 {
+		commands = append(commands, []string{"SELECT", strconv.Itoa(c.opts.DB)})
+	}
+
+	for _, args := range commands {
+		reply, err := cn.do(ctx, c.opts.Timeout, args...)
+		if err != nil {
+			return err
+		}
+		if e, ok := reply.(Error); ok {
+			return fmt.Errorf("failed to %s: %w", strings.ToLower(args[0]), e)
+		}
+	}
+
+	return nil
+}
+
+// release returns connection to pool or closes it when pool is full.
+func (c *Cache) release(cn *conn) {
+	select {
+	case c.pool <- cn:
+	default:
+		_ = cn.nc.Close()
+	}
+}
+
+func (cn *conn) do(ctx context.Context, timeout time.Duration, args ...string) (any, error) {
+	replies, err := cn.pipeline(ctx, timeout, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return replies[0], nil
+}
+
+// pipeline writes all commands before reading replies, so they take single round trip.
+func (cn *conn) pipeline(ctx context.Context, timeout time.Duration, cmds ...[]string) ([]any, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(timeout)
+	}
+
+	if err := cn.nc.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("failed to set deadline: %w", err)
+	}
+
+	for _, args := range cmds {
+		if err := writeCommand(cn.w, args...); err != nil {
+			return nil, fmt.Errorf("failed to send %s: %w", args[0], err)
+		}
+	}
+
+	replies := make([]any, len(cmds))
+	for i, args := range cmds {
+		reply, err := readReply(cn.r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s reply: %w", args[0], err)
+		}
+
+		replies[i] = reply
+	}
+
+	return replies, nil
+}
+
+func (c *Cache) entryKey(key string) string {
+	return c.opts.Prefix + "entry:" + key
+}
+
+func (c *Cache) hitsKey(key string) string {
+	return c.opts.Prefix + "hits:" + key
+}
+
+func (c *Cache) seenKey(key string) string {
+	return c.opts.Prefix + "seen:" + key
+}
+
+func (c *Cache) idKey(id int64) string {
+	return c.opts.Prefix + "id:" + strconv.FormatInt(id, 10)
+}
+
+// withTTL adds expiration to SET command.
+func (c *Cache) withTTL(args ...string) []string {
+	if c.ttl > 0 {
+		args = append(args, "EX", strconv.FormatInt(c.ttl, 10))
+	}
+
+	return args
+}
+
+// encodeEntry stores entry id and creation time together with value, so all of them are read by single command.
+func encodeEntry(id, created int64, value []byte) string {
+	return strconv.FormatInt(id, 10) + ":" + strconv.FormatInt(created, 10) + ":" + string(value)
+}
+
+// decodeEntry parses entry value, expiration time is derived from creation time.
+func (c *Cache) decodeEntry(reply any) (*cachepkg.Entry, error) {
+	raw, ok := reply.([]byte)
+	if !ok {
+		return nil, errBadEntry
+	}
+
+	parts := bytes.SplitN(raw, []byte(":"), 3)
+	if len(parts) != 3 {
+		return nil, errBadEntry
+	}
+
+	id, err := strconv.ParseInt(string(parts[0]), 10, 64)
+	if err != nil {
+		return nil, errBadEntry
+	}
+
+	created, err := strconv.ParseInt(string(parts[1]), 10, 64)
+	if err != nil {
+		return nil, errBadEntry
+	}
+
+	res := &cachepkg.Entry{ID: id, Val: parts[2], CreatedAt: cachepkg.UnixTime(created)}
+	if c.ttl > 0 {
+		res.ExpiresAt = res.CreatedAt.Add(time.Duration(c.ttl) * time.Second)
+	}
+
+	return res, nil
+}
+
+// setReads fills read counter and time of the last read from replies of hits and seen keys.
+func setReads(e *cachepkg.Entry, hits, seen any) {
+	if v, ok := hits.([]byte); ok {
+		e.Hits, _ = strconv.ParseInt(string(v), 10, 64)
+	}
+
+	if v, ok := seen.([]byte); ok {
+		sec, _ := strconv.ParseInt(string(v), 10, 64)
+		e.LastAccess = cachepkg.UnixTime(sec)
+	}
+}
+
+// escapePattern escapes glob special characters of SCAN pattern.
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
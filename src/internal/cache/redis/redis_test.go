@@ -0,0 +1,274 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	cachepkg "mortgage-calculator/src/internal/cache"
+	"mortgage-calculator/src/internal/cache/redis/redistest"
+	"mortgage-calculator/src/internal/lib/random"
+	"sort"
+	"testing"
+	"time"
+)
+
+func setup(t *testing.T, ttl int64, opts Options) (*Cache, *redistest.Server, context.Context) {
+	t.Helper()
+
+	srv, err := redistest.New(opts.Password)
+	require.NoError(t, err)
+	t.Cleanup(srv.Close)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	opts.Addr = srv.Addr()
+	c := New(log, ttl, opts)
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c, srv, context.Background()
+}
+
+func TestCache_Get_NonexistentKey(t *testing.T) {
+	c, _, ctx := setup(t, 100, Options{})
+
+	key, _ := random.String(10)
+	val, err := c.Get(ctx, key)
+	require.ErrorIs(t, err, cachepkg.ErrKeyNotExists)
+	require.Empty(t, val)
+}
+
+func TestCache_Get_NonexistentKey_NoCounters(t *testing.T) {
+	c, _, ctx := setup(t, 0, Options{})
+
+	_, err := c.Get(ctx, "a")
+	require.ErrorIs(t, err, cachepkg.ErrKeyNotExists)
+
+	// read of missing entry leaves no keys behind
+	keys, err := c.scan(ctx, "*")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
+
+func TestCache_Set(t *testing.T) {
+	c, _, ctx := setup(t, 100, Options{})
+
+	key := `{"object_cost": 5000000, "program": {"salary": true}}`
+	val := "value:with\r\nseparators"
+
+	require.NoError(t, c.Set(ctx, key, []byte(val)))
+
+	res, err := c.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, val, string(res))
+}
+
+func TestCache_Set_Expiration(t *testing.T) {
+	c, srv, ctx := setup(t, 100, Options{})
+
+	key, _ := random.String(10)
+	require.NoError(t, c.Set(ctx, key, []byte(key)))
+
+	srv.FastForward(100 * time.Second)
+
+	_, err := c.Get(ctx, key)
+	require.ErrorIs(t, err, cachepkg.ErrKeyNotExists)
+
+	list, err := c.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
+
+func TestCache_Set_NoExpiration(t *testing.T) {
+	c, srv, ctx := setup(t, 0, Options{})
+
+	key, _ := random.String(10)
+	require.NoError(t, c.Set(ctx, key, []byte(key)))
+
+	srv.FastForward(24 * time.Hour)
+
+	res, err := c.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, key, string(res))
+}
+
+func TestCache_List(t *testing.T) {
+	c, _, ctx := setup(t, 100, Options{Prefix: "test*"})
+
+	// more entries than one SCAN and MGET batch
+	const n = 250
+
+	for i := range n {
+		require.NoError(t, c.Set(ctx, fmt.Sprintf("key%03d", i), []byte(fmt.Sprintf("val%03d", i))))
+	}
+
+	list, err := c.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, n)
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	for i, e := range list {
+		require.Equal(t, fmt.Sprintf("key%03d", i), e.Key)
+		require.Equal(t, fmt.Sprintf("val%03d", i), string(e.Val))
+		require.Equal(t, int64(i+1), e.ID)
+	}
+}
+
+func TestCache_List_EmptyCache(t *testing.T) {
+	c, _, ctx := setup(t, 100, Options{})
+
+	list, err := c.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
+
+func TestCache_Stats(t *testing.T) {
+	c, srv, ctx := setup(t, 100, Options{Prefix: "test:", Timeout: time.Second})
+
+	for i := range 3 {
+		require.NoError(t, c.Set(ctx, fmt.Sprintf("key%d", i), []byte("val")))
+	}
+	_, err := c.Get(ctx, "key0")
+	require.NoError(t, err)
+
+	// read counters aren't counted as entries
+	require.Equal(t, cachepkg.Stats{Entries: 3}, c.Stats(ctx))
+
+	// result is reused until it gets outdated
+	require.NoError(t, c.Set(ctx, "key3", []byte("val")))
+	require.Equal(t, cachepkg.Stats{Entries: 3}, c.Stats(ctx))
+
+	c.statsAt = time.Now().Add(-statsTTL)
+	require.Equal(t, cachepkg.Stats{Entries: 4}, c.Stats(ctx))
+
+	srv.Close()
+	_ = c.Close()
+	c.statsAt = time.Time{}
+
+	require.Equal(t, cachepkg.Stats{}, c.Stats(ctx))
+}
+
+func TestCache_Auth(t *testing.T) {
+	c, _, ctx := setup(t, 100, Options{Password: "secret", DB: 1})
+
+	require.NoError(t, c.Ping(ctx))
+
+	c.opts.Password = "wrong"
+	_ = c.Close()
+
+	require.Error(t, c.Ping(ctx))
+}
+
+func TestCache_Unavailable(t *testing.T) {
+	c, srv, ctx := setup(t, 100, Options{Timeout: time.Second})
+
+	srv.Close()
+	_ = c.Close()
+
+	require.Error(t, c.Ping(ctx))
+	require.Error(t, c.Set(ctx, "key", []byte("val")))
+}
+
+func TestCache_Clear(t *testing.T) {
+	c, _, ctx := setup(t, 100, Options{})
+
+	require.NoError(t, c.Set(ctx, "key", []byte("val")))
+
+	// Clear doesn't remove active entries
+	require.Zero(t, c.Clear(ctx))
+
+	res, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, "val", string(res))
+}
+
+func TestCache_GetByID(t *testing.T) {
+	c, srv, ctx := setup(t, 100, Options{})
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "a", []byte("2")))
+
+	_, err := c.GetByID(ctx, 1)
+	require.ErrorIs(t, err, cachepkg.ErrKeyNotExists)
+
+	res, err := c.GetByID(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), res.ID)
+	require.Equal(t, "a", res.Key)
+	require.Equal(t, "2", string(res.Val))
+
+	srv.FastForward(100 * time.Second)
+
+	_, err = c.GetByID(ctx, 2)
+	require.ErrorIs(t, err, cachepkg.ErrKeyNotExists)
+}
+
+func TestCache_Delete(t *testing.T) {
+	c, _, ctx := setup(t, 100, Options{})
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "b", []byte("2")))
+
+	require.NoError(t, c.Delete(ctx, 1))
+	require.ErrorIs(t, c.Delete(ctx, 1), cachepkg.ErrKeyNotExists)
+
+	list, err := c.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "b", list[0].Key)
+}
+
+func TestCache_Purge(t *testing.T) {
+	c, _, ctx := setup(t, 100, Options{})
+
+	for i := range 150 {
+		require.NoError(t, c.Set(ctx, fmt.Sprintf("key%d", i), []byte("val")))
+	}
+
+	n, err := c.Purge(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 150, n)
+
+	list, err := c.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	// ids keep growing
+	require.NoError(t, c.Set(ctx, "key", []byte("val")))
+	res, err := c.GetByID(ctx, 151)
+	require.NoError(t, err)
+	require.Equal(t, "key", res.Key)
+}
+
+func TestCache_Metadata(t *testing.T) {
+	c, _, ctx := setup(t, 100, Options{})
+
+	before := time.Now().Truncate(time.Second)
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+
+	res, err := c.GetByID(ctx, 1)
+	require.NoError(t, err)
+	require.False(t, res.CreatedAt.Before(before))
+	require.Equal(t, res.CreatedAt.Add(100*time.Second), res.ExpiresAt)
+	require.Zero(t, res.Hits)
+	require.True(t, res.LastAccess.IsZero())
+
+	for range 2 {
+		_, err = c.Get(ctx, "a")
+		require.NoError(t, err)
+	}
+
+	list, err := c.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, int64(2), list[0].Hits)
+	require.False(t, list[0].LastAccess.Before(res.CreatedAt))
+
+	// overwritten entry is counted anew
+	require.NoError(t, c.Set(ctx, "a", []byte("2")))
+
+	res, err = c.GetByID(ctx, 2)
+	require.NoError(t, err)
+	require.Zero(t, res.Hits)
+	require.True(t, res.LastAccess.IsZero())
+}
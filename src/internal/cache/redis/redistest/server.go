@@ -0,0 +1,366 @@
+// Package redistest provides in-process server speaking subset of Redis protocol for tests.
+package redistest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errBadRequest = errors.New("bad request")
+
+// Server is an in-memory RESP server supporting commands used by cache:
+// PING, AUTH, SELECT, GET, SET (with EX), INCR, EXPIRE, MGET, DEL and SCAN (with MATCH and COUNT).
+type Server struct {
+	ln       net.Listener
+	password string
+
+	mu     sync.Mutex
+	offset time.Duration
+	data   map[string]item
+	wg     sync.WaitGroup
+}
+
+type item struct {
+	val string
+	exp time.Time
+}
+
+// New starts server on random local port.
+// Non-empty password makes server require AUTH.
+func New(password string) (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	s := &Server{
+		ln:       ln,
+		password: password,
+		data:     make(map[string]item),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+// Addr returns server address.
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// FastForward moves server time forward, so entries expire without waiting.
+func (s *Server) FastForward(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offset += d
+}
+
+// Close stops server.
+func (s *Server) Close() {
+	_ = s.ln.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		nc, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(nc)
+	}
+}
+
+func (s *Server) handle(nc net.Conn) {
+	defer func() { _ = nc.Close() }()
+
+	r := bufio.NewReader(nc)
+	w := bufio.NewWriter(nc)
+	authorized := s.password == ""
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				writeError(w, err.Error())
+				_ = w.Flush()
+			}
+			return
+		}
+
+		cmd := strings.ToUpper(args[0])
+		switch {
+		case cmd == "AUTH":
+			authorized = len(args) == 2 && args[1] == s.password
+			if !authorized {
+				writeError(w, "WRONGPASS invalid password")
+				break
+			}
+			writeSimple(w, "OK")
+		case !authorized:
+			writeError(w, "NOAUTH Authentication required")
+		default:
+			s.exec(w, cmd, args[1:])
+		}
+
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+//nolint:cyclop // plain command dispatch
+func (s *Server) exec(w *bufio.Writer, cmd string, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Add(s.offset)
+
+	switch {
+	case cmd == "PING":
+		writeSimple(w, "PONG")
+	case cmd == "SELECT" && len(args) == 1:
+		writeSimple(w, "OK")
+	case cmd == "GET" && len(args) == 1:
+		writeBulk(w, s.get(now, args[0]))
+	case cmd == "MGET" && len(args) > 0:
+		fmt.Fprintf(w, "*%d\r\n", len(args))
+		for _, k := range args {
+			writeBulk(w, s.get(now, k))
+		}
+	case cmd == "SET" && (len(args) == 2 || len(args) == 4 && strings.EqualFold(args[2], "EX")):
+		it := item{val: args[1]}
+		if len(args) == 4 {
+			ttl, err := strconv.Atoi(args[3])
+			if err != nil || ttl <= 0 {
+				writeError(w, "ERR invalid expire time in 'set' command")
+				return
+			}
+			it.exp = now.Add(time.Duration(ttl) * time.Second)
+		}
+		s.data[args[0]] = it
+		writeSimple(w, "OK")
+	case cmd == "INCR" && len(args) == 1:
+		s.incr(w, now, args[0])
+	case cmd == "EXPIRE" && len(args) == 2:
+		s.expire(w, now, args[0], args[1])
+	case cmd == "DEL" && len(args) > 0:
+		var n int
+		for _, k := range args {
+			if s.get(now, k) != nil {
+				delete(s.data, k)
+				n++
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", n)
+	case cmd == "SCAN" && len(args) > 0:
+		s.scan(w, now, args)
+	default:
+		writeError(w, fmt.Sprintf("ERR unknown command or wrong number of arguments for '%s'", strings.ToLower(cmd)))
+	}
+}
+
+func (s *Server) get(now time.Time, key string) *string {
+	it, ok := s.data[key]
+	if !ok {
+		return nil
+	}
+
+	if !it.exp.IsZero() && !now.Before(it.exp) {
+		delete(s.data, key)
+		return nil
+	}
+
+	return &it.val
+}
+
+func (s *Server) incr(w *bufio.Writer, now time.Time, key string) {
+	var n int64
+	if v := s.get(now, key); v != nil {
+		var err error
+		if n, err = strconv.ParseInt(*v, 10, 64); err != nil {
+			writeError(w, "ERR value is not an integer or out of range")
+			return
+		}
+	}
+
+	n++
+	s.data[key] = item{val: strconv.FormatInt(n, 10), exp: s.data[key].exp}
+	fmt.Fprintf(w, ":%d\r\n", n)
+}
+
+func (s *Server) expire(w *bufio.Writer, now time.Time, key, seconds string) {
+	ttl, err := strconv.Atoi(seconds)
+	if err != nil {
+		writeError(w, "ERR value is not an integer or out of range")
+		return
+	}
+
+	if s.get(now, key) == nil {
+		fmt.Fprint(w, ":0\r\n")
+		return
+	}
+
+	s.data[key] = item{val: s.data[key].val, exp: now.Add(time.Duration(ttl) * time.Second)}
+	fmt.Fprint(w, ":1\r\n")
+}
+
+// scan walks keys in sorted order, cursor is an index of the next key.
+func (s *Server) scan(w *bufio.Writer, now time.Time, args []string) {
+	cursor, err := strconv.Atoi(args[0])
+	if err != nil {
+		writeError(w, "ERR invalid cursor")
+		return
+	}
+
+	pattern, count := "*", 10
+	for i := 1; i+1 < len(args); i += 2 {
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			pattern = args[i+1]
+		case "COUNT":
+			if count, err = strconv.Atoi(args[i+1]); err != nil || count <= 0 {
+				writeError(w, "ERR syntax error")
+				return
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		if s.get(now, k) != nil {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var found []string
+	next := 0
+	for i := cursor; i < len(keys); i++ {
+		if i-cursor == count {
+			next = i
+			break
+		}
+		if match(pattern, keys[i]) {
+			found = append(found, keys[i])
+		}
+	}
+
+	cur := strconv.Itoa(next)
+	fmt.Fprintf(w, "*2\r\n")
+	writeBulk(w, &cur)
+	fmt.Fprintf(w, "*%d\r\n", len(found))
+	for _, k := range found {
+		writeBulk(w, &k)
+	}
+}
+
+// match reports whether key matches glob pattern with '*', '?' and backslash escapes.
+func match(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(key); i >= 0; i-- {
+				if match(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if key == "" {
+				return false
+			}
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if key == "" || key[0] != pattern[0] {
+				return false
+			}
+		}
+
+		pattern, key = pattern[1:], key[1:]
+	}
+
+	return key == ""
+}
+
+// readCommand reads RESP array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 2 || line[0] != '*' {
+		return nil, fmt.Errorf("ERR %w: expected array", errBadRequest)
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("ERR %w: bad array length", errBadRequest)
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if line, err = readLine(r); err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.Atoi(strings.TrimPrefix(line, "$"))
+		if err != nil || !strings.HasPrefix(line, "$") || size < 0 {
+			return nil, fmt.Errorf("ERR %w: expected bulk string", errBadRequest)
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err //nolint:wrapcheck // connection is closed anyway
+		}
+
+		args[i] = string(buf[:size])
+	}
+
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err //nolint:wrapcheck // connection is closed anyway
+	}
+
+	return strings.TrimSuffix(line, "\r\n"), nil
+}
+
+func writeSimple(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "+%s\r\n", s)
+}
+
+func writeError(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "-%s\r\n", s)
+}
+
+func writeBulk(w *bufio.Writer, s *string) {
+	if s == nil {
+		fmt.Fprint(w, "$-1\r\n")
+		return
+	}
+
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(*s), *s)
+}
@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrProtocol represents error when server reply doesn't follow RESP.
+var ErrProtocol = errors.New("redis protocol error")
+
+// Error represents error reply of the server.
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// writeCommand encodes command as RESP array of bulk strings.
+func writeCommand(w *bufio.Writer, args ...string) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err //nolint:wrapcheck // wrapped by caller
+	}
+
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return err //nolint:wrapcheck // wrapped by caller
+		}
+	}
+
+	return w.Flush() //nolint:wrapcheck // wrapped by caller
+}
+
+// readReply decodes single RESP reply.
+// Simple strings are returned as string, integers as int64, bulk strings as []byte,
+// null bulk strings and arrays as nil, arrays as []any and error replies as Error.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		return nil, fmt.Errorf("%w: empty reply", ErrProtocol)
+	}
+
+	switch line[0] {
+	case '+':
+		return string(line[1:]), nil
+	case '-':
+		return Error(line[1:]), nil
+	case ':':
+		return parseInt(line[1:])
+	case '$':
+		return readBulk(r, line[1:])
+	case '*':
+		return readArray(r, line[1:])
+	default:
+		return nil, fmt.Errorf("%w: unexpected reply type %q", ErrProtocol, line[0])
+	}
+}
+
+func readBulk(r *bufio.Reader, header []byte) (any, error) {
+	n, err := parseInt(header)
+	if err != nil || n < 0 {
+		return nil, err
+	}
+
+	buf := make([]byte, n+2)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by caller
+	}
+
+	return buf[:n], nil
+}
+
+func readArray(r *bufio.Reader, header []byte) (any, error) {
+	n, err := parseInt(header)
+	if err != nil || n < 0 {
+		return nil, err
+	}
+
+	res := make([]any, n)
+	for i := range res {
+		if res[i], err = readReply(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by caller
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("%w: line is not terminated by CRLF", ErrProtocol)
+	}
+
+	return line[:len(line)-2], nil
+}
+
+func parseInt(b []byte) (int64, error) {
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad integer %q", ErrProtocol, b)
+	}
+
+	return n, nil
+}
@@ -3,36 +3,74 @@ package cacherepos
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	cachepkg "mortgage-calculator/src/internal/cache"
 	"mortgage-calculator/src/internal/domain/dto"
 	"mortgage-calculator/src/internal/domain/dto/requests"
+	"mortgage-calculator/src/internal/lib/clock"
+	"mortgage-calculator/src/internal/lib/singleflight"
+	"mortgage-calculator/src/internal/lib/tracing"
+	"mortgage-calculator/src/internal/logger"
+	"sync"
+	"time"
 )
 
+// errStaleEntry represents error when cached value has format of another version.
+var errStaleEntry = errors.New("cache entry has stale format")
+
 // Cache represents cache api.
 type Cache interface {
 	Get(ctx context.Context, key string) ([]byte, error)
 	Set(ctx context.Context, key string, value []byte) error
-	Clear(ctx context.Context)
+	Clear(ctx context.Context) int
 	List(ctx context.Context) ([]*cachepkg.Entry, error)
+	GetByID(ctx context.Context, id int64) (*cachepkg.Entry, error)
+	Delete(ctx context.Context, id int64) error
+	Purge(ctx context.Context) (int, error)
+}
+
+// statsProvider is implemented by caches counting their size and removed entries.
+type statsProvider interface {
+	Stats(ctx context.Context) cachepkg.Stats
+}
+
+// Refresh configures recalculation of entries about to expire, zero Ahead disables it.
+type Refresh struct {
+	TTL   time.Duration // TTL is lifetime of cache entries.
+	Ahead time.Duration // Ahead is time before expiration since which hit triggers recalculation.
 }
 
 // CalcRepository is a repo to save and retrieve calculation results.
 type CalcRepository struct {
-	log   *slog.Logger
-	cache Cache
+	log       *slog.Logger
+	cache     Cache
+	clock     clock.Clock
+	refresh   Refresh
+	usage     *usage
+	flight    singleflight.Group[*dto.CalcAggregates]
+	refreshes sync.WaitGroup // refreshes tracks background recalculations of expiring entries.
+	// fingerprint identifies configuration results depend on, entries cached with another one are never read.
+	fingerprint string
 }
 
 // NewCalcRepository is a constructor for CalcRepository.
+// Fingerprint is a part of every key, so changing configuration of calculations invalidates cached results.
 func NewCalcRepository(
 	log *slog.Logger,
 	cache Cache,
+	clk clock.Clock,
+	refresh Refresh,
+	fingerprint string,
 ) *CalcRepository {
 	return &CalcRepository{
-		log:   log,
-		cache: cache,
+		log:         log,
+		cache:       cache,
+		clock:       clk,
+		refresh:     refresh,
+		usage:       newUsage(),
+		fingerprint: fingerprint,
 	}
 }
 
@@ -42,11 +80,11 @@ func (r *CalcRepository) Get(
 	in *requests.CalculateRequest,
 ) (*dto.CalcAggregates, error) {
 	const op = "cacherepos.calcRepository.Get"
-	log := r.log.With(slog.String("op", op))
+	log := logger.FromContext(ctx, r.log).With(slog.String("op", op))
 
 	log.Info("generating key")
 
-	key, err := generateKey(in)
+	key, err := generateKey(in, r.fingerprint)
 	if err != nil {
 		log.Error("failed to generate key", slog.Any("error", err))
 
@@ -56,51 +94,53 @@ func (r *CalcRepository) Get(
 	log = log.With(slog.String("key", key))
 	log.Info("key generated, trying to retrieve key from cache")
 
-	byteArr, err := r.cache.Get(ctx, key)
+	env, err := r.lookup(ctx, key)
 	if err != nil {
-		log.Info("failed to retrieve key from cache", slog.Any("error", err))
+		log.Info("failed to retrieve result from cache", slog.Any("error", err))
+		r.usage.miss()
 
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	log.Info("cache hit, unmarshalling result")
-
-	var res dto.CalcAggregates
-	err = json.Unmarshal(byteArr, &res)
-	if err != nil {
-		log.Info("failed to unmarshal result", slog.Any("error", err))
-
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
+	log.Info("cache hit")
+	r.usage.request(key, in)
+	r.usage.hit()
 
-	return &res, nil
+	return env.Aggregates, nil
 }
 
 // Set generates key by input, marshals result and caches it.
+// Input is stored as is, so borrower is expected to be stripped by caller.
 func (r *CalcRepository) Set(
 	ctx context.Context,
 	in *requests.CalculateRequest,
 	aggregates *dto.CalcAggregates,
 ) error {
 	const op = "cacherepos.calcRepository.Set"
-	log := r.log.With(slog.String("op", op))
+	log := logger.FromContext(ctx, r.log).With(slog.String("op", op))
+
+	ctx, span := tracing.Start(ctx, "CalcRepository.Set")
+	defer span.End()
 
 	log.Info("generating key")
 
-	key, err := generateKey(in)
+	key, err := generateKey(in, r.fingerprint)
 	if err != nil {
 		log.Error("failed to generate key", slog.Any("error", err))
+		span.RecordError(err)
 
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	log = log.With(slog.String("key", key))
+	span.SetAttributes(tracing.String("cache.key_hash", keyHash(key)))
 
 	log.Info("key generated, marshalling data")
 
-	byteArr, err := json.Marshal(aggregates)
+	byteArr, err := encodeEnvelope(in, aggregates, r.clock.Now())
 	if err != nil {
 		log.Error("failed to marshal data", slog.Any("error", err))
+		span.RecordError(err)
 
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -110,27 +150,258 @@ func (r *CalcRepository) Set(
 	err = r.cache.Set(ctx, key, byteArr)
 	if err != nil {
 		log.Error("failed to save data", slog.Any("error", err))
+		span.RecordError(err)
 
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	r.usage.set()
+
 	return nil
 }
 
-// Clear cleans expired items from cache.
-func (r *CalcRepository) Clear(ctx context.Context) {
+// GetOrCompute returns cached result or computes and caches it.
+// Concurrent misses of the same request share one computation.
+// Hit of entry about to expire returns it and recomputes it in background.
+func (r *CalcRepository) GetOrCompute(
+	ctx context.Context,
+	in *requests.CalculateRequest,
+	compute func(ctx context.Context) (*dto.CalcAggregates, error),
+) (*dto.CalcAggregates, error) {
+	const op = "cacherepos.calcRepository.GetOrCompute"
+	log := logger.FromContext(ctx, r.log).With(slog.String("op", op))
+
+	key, err := generateKey(in, r.fingerprint)
+	if err != nil {
+		log.Error("failed to generate key", slog.Any("error", err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.String("key", key))
+
+	env, err := r.lookup(ctx, key)
+	if err == nil {
+		r.usage.request(key, in)
+		r.usage.hit()
+
+		if r.expiring(env) {
+			log.Info("cache entry is about to expire, refreshing it")
+			r.refreshes.Add(1)
+			go func() {
+				defer r.refreshes.Done()
+				r.refreshEntry(context.WithoutCancel(ctx), key, in, compute)
+			}()
+		}
+
+		return env.Aggregates, nil
+	}
+
+	log.Info("cache miss, computing result", slog.Any("error", err))
+
+	// computation is shared by callers, so it isn't cancelled with context of one of them
+	computeCtx := context.WithoutCancel(ctx)
+	res, shared, err := r.flight.Do(ctx, key, func() (*dto.CalcAggregates, error) {
+		// result may have been cached by call finished after lookup
+		if env, err := r.lookup(computeCtx, key); err == nil {
+			return env.Aggregates, nil
+		}
+
+		return r.compute(computeCtx, key, in, compute)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// invalid requests are not counted, so they don't skew hit ratio and usage
+	r.usage.request(key, in)
+	r.usage.miss()
+	if shared {
+		r.usage.coalesce()
+	}
+
+	return res, nil
+}
+
+// lookup retrieves and decodes cached value by key.
+// Missing and stale entries are traced as cache misses rather than errors.
+func (r *CalcRepository) lookup(ctx context.Context, key string) (*envelope, error) {
+	ctx, span := tracing.Start(ctx, "CalcRepository.Get", tracing.String("cache.key_hash", keyHash(key)))
+	defer span.End()
+
+	env, err := r.read(ctx, key)
+
+	span.SetAttributes(tracing.Bool("cache.hit", err == nil))
+	if err != nil && !errors.Is(err, cachepkg.ErrKeyNotExists) && !errors.Is(err, errStaleEntry) {
+		span.RecordError(err)
+	}
+
+	return env, err
+}
+
+// read retrieves and decodes cached value by key.
+func (r *CalcRepository) read(ctx context.Context, key string) (*envelope, error) {
+	byteArr, err := r.cache.Get(ctx, key)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by caller
+	}
+
+	return decodeEnvelope(byteArr)
+}
+
+// expiring reports whether entry is to be refreshed before expiration.
+// Entries cached without time are never refreshed.
+func (r *CalcRepository) expiring(env *envelope) bool {
+	if r.refresh.Ahead <= 0 || r.refresh.TTL <= 0 || env.CachedAt == 0 {
+		return false
+	}
+
+	expiresAt := time.Unix(env.CachedAt, 0).Add(r.refresh.TTL)
+
+	return !r.clock.Now().Before(expiresAt.Add(-r.refresh.Ahead))
+}
+
+// compute computes result and caches it, failure to cache doesn't fail computation.
+func (r *CalcRepository) compute(
+	ctx context.Context,
+	key string,
+	in *requests.CalculateRequest,
+	compute func(ctx context.Context) (*dto.CalcAggregates, error),
+) (*dto.CalcAggregates, error) {
+	res, err := compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Set(ctx, in, res); err != nil {
+		logger.FromContext(ctx, r.log).Warn("failed to cache result", slog.String("key", key), slog.Any("error", err))
+	}
+
+	return res, nil
+}
+
+// refreshEntry recomputes entry unless it is being computed or has been refreshed already.
+func (r *CalcRepository) refreshEntry(
+	ctx context.Context,
+	key string,
+	in *requests.CalculateRequest,
+	compute func(ctx context.Context) (*dto.CalcAggregates, error),
+) {
+	const op = "cacherepos.calcRepository.refreshEntry"
+	log := logger.FromContext(ctx, r.log).With(slog.String("op", op), slog.String("key", key))
+
+	_, shared, err := r.flight.Do(ctx, key, func() (*dto.CalcAggregates, error) {
+		if env, err := r.lookup(ctx, key); err == nil && !r.expiring(env) {
+			return env.Aggregates, nil
+		}
+
+		return r.compute(ctx, key, in, compute)
+	})
+	if err != nil {
+		log.Warn("failed to refresh cache entry", slog.Any("error", err))
+		return
+	}
+
+	log.Info("cache entry refreshed", slog.Bool("shared", shared))
+}
+
+// Wait waits for background refreshes of cache entries, so cache may be closed after it.
+// It returns error when ctx is done before refreshes finish.
+func (r *CalcRepository) Wait(ctx context.Context) error {
+	const op = "cacherepos.calcRepository.Wait"
+
+	done := make(chan struct{})
+	go func() {
+		r.refreshes.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+}
+
+// Clear cleans expired items from cache and returns number of deleted items.
+func (r *CalcRepository) Clear(ctx context.Context) int {
 	const op = "cacherepos.calcRepository.Clear"
-	log := r.log.With(slog.String("op", op))
+	log := logger.FromContext(ctx, r.log).With(slog.String("op", op))
 
 	log.Info("clearing expired cache entries")
-	r.cache.Clear(ctx)
-	log.Info("deleted expired items from cache")
+	res := r.cache.Clear(ctx)
+	log.Info("deleted expired items from cache", slog.Int("deleted", res))
+
+	return res
+}
+
+// GetByID returns cache item by id.
+func (r *CalcRepository) GetByID(ctx context.Context, id int64) (*dto.CacheEntry, error) {
+	const op = "cacherepos.calcRepository.GetByID"
+	log := logger.FromContext(ctx, r.log).With(slog.String("op", op), slog.Int64("id", id))
+
+	log.Info("retrieving cache item")
+
+	item, err := r.cache.GetByID(ctx, id)
+	if err != nil {
+		log.Info("failed to retrieve cache item", slog.Any("error", err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := entryOf(item)
+	if errors.Is(err, errStaleEntry) {
+		return nil, fmt.Errorf("%s: %w", op, cachepkg.ErrKeyNotExists)
+	}
+	if err != nil {
+		log.Info("failed to unmarshal cache item", slog.Any("error", err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// Delete deletes cache item by id.
+func (r *CalcRepository) Delete(ctx context.Context, id int64) error {
+	const op = "cacherepos.calcRepository.Delete"
+	log := logger.FromContext(ctx, r.log).With(slog.String("op", op), slog.Int64("id", id))
+
+	log.Info("deleting cache item")
+
+	if err := r.cache.Delete(ctx, id); err != nil {
+		log.Info("failed to delete cache item", slog.Any("error", err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Purge deletes all cache items and returns their number.
+func (r *CalcRepository) Purge(ctx context.Context) (int, error) {
+	const op = "cacherepos.calcRepository.Purge"
+	log := logger.FromContext(ctx, r.log).With(slog.String("op", op))
+
+	log.Info("purging cache")
+
+	res, err := r.cache.Purge(ctx)
+	if err != nil {
+		log.Error("failed to purge cache", slog.Any("error", err))
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("cache purged", slog.Int("deleted", res))
+
+	return res, nil
 }
 
 // List lists all active items.
 func (r *CalcRepository) List(ctx context.Context) ([]*dto.CacheEntry, error) {
 	const op = "cacherepos.calcRepository.List"
-	log := r.log.With(slog.String("op", op))
+	log := logger.FromContext(ctx, r.log).With(slog.String("op", op))
 
 	log.Info("retrieving all cache items")
 
@@ -142,44 +413,95 @@ func (r *CalcRepository) List(ctx context.Context) ([]*dto.CacheEntry, error) {
 
 	log.Info("cache items retrieved, unmarshalling")
 
-	res := make([]*dto.CacheEntry, len(items))
+	res := make([]*dto.CacheEntry, 0, len(items))
 
-	for i, item := range items {
-		var aggregates dto.CalcAggregates
-		err := json.Unmarshal(item.Val, &aggregates)
-		if err != nil {
-			log.Info("failed to unmarshal aggregates")
-			return nil, fmt.Errorf("%s: %w", op, err)
+	for _, item := range items {
+		entry, err := entryOf(item)
+		// entries of previous format are left to expire
+		if errors.Is(err, errStaleEntry) {
+			log.Debug("stale cache item skipped", slog.Int64("id", item.ID))
+			continue
 		}
-
-		var params requests.CalculateRequest
-		err = json.Unmarshal([]byte(item.Key), &params)
 		if err != nil {
-			log.Info("failed to unmarshal params")
+			log.Info("failed to unmarshal cache item", slog.Any("error", err))
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 
-		res[i] = &dto.CacheEntry{
-			ID:         item.ID,
-			Aggregates: &aggregates,
-			Params: &dto.CalcParams{
-				ObjectCost:     params.ObjectCost,
-				InitialPayment: params.InitialPayment,
-				Months:         params.Months,
-			},
-			Program: &params.Program,
-		}
+		res = append(res, entry)
 	}
 
 	return res, nil
 }
 
-func generateKey(in *requests.CalculateRequest) (string, error) {
-	byteArr, err := json.Marshal(in)
-
+// entryOf decodes request and aggregates from value of cache item.
+func entryOf(item *cachepkg.Entry) (*dto.CacheEntry, error) {
+	env, err := decodeEnvelope(item.Val)
 	if err != nil {
-		return "", fmt.Errorf("failed to unmarshal data: %w", err)
+		return nil, err
 	}
 
-	return string(byteArr), nil
+	return &dto.CacheEntry{
+		ID:         item.ID,
+		Aggregates: env.Aggregates,
+		Params:     &env.Request.CalcParams,
+		Program:    &env.Request.Program,
+		CreatedAt:  item.CreatedAt.UTC(),
+		ExpiresAt:  timeOrNil(item.ExpiresAt),
+		Hits:       item.Hits,
+		LastAccess: timeOrNil(item.LastAccess),
+	}, nil
+}
+
+// timeOrNil returns nil for zero time, so it is omitted in response.
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	t = t.UTC()
+
+	return &t
+}
+
+// Stats returns cache usage counters, size of cache and all tracked requests sorted by count.
+// Size is counted by listing entries when cache doesn't count it itself.
+func (r *CalcRepository) Stats(ctx context.Context) (*dto.CacheStats, error) {
+	const op = "cacherepos.calcRepository.Stats"
+	log := logger.FromContext(ctx, r.log).With(slog.String("op", op))
+
+	u := r.usage.snapshot()
+	res := &dto.CacheStats{
+		Hits:        u.hits,
+		Misses:      u.misses,
+		Sets:        u.sets,
+		Coalesced:   u.coalesced,
+		TopRequests: make([]*dto.RequestCount, 0, len(u.requests)),
+		Programs:    u.programs,
+		Terms:       u.terms,
+	}
+
+	if p, ok := r.cache.(statsProvider); ok {
+		stats := p.Stats(ctx)
+		res.Entries = stats.Entries
+		res.Evictions = stats.Evictions
+		res.Expirations = stats.Expirations
+	} else {
+		items, err := r.cache.List(ctx)
+		if err != nil {
+			log.Error("failed to retrieve cache items", slog.Any("error", err))
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res.Entries = len(items)
+	}
+
+	for _, req := range u.requests {
+		res.TopRequests = append(res.TopRequests, &dto.RequestCount{
+			Params:  &req.request.CalcParams,
+			Program: &req.request.Program,
+			Count:   req.count,
+		})
+	}
+
+	return res, nil
 }
@@ -2,63 +2,110 @@ package cacherepos
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"io"
 	"log/slog"
 	cachepkg "mortgage-calculator/src/internal/cache"
+	"mortgage-calculator/src/internal/cache/memory"
 	"mortgage-calculator/src/internal/domain/dto"
 	"mortgage-calculator/src/internal/domain/dto/requests"
+	"mortgage-calculator/src/internal/lib/clock"
+	"mortgage-calculator/src/internal/lib/money"
 	cachemock "mortgage-calculator/src/internal/mocks/cache"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
+// cachedAt is time of caching in tests.
+var cachedAt = time.Date(2024, 6, 18, 0, 0, 0, 0, time.UTC)
+
 func setup() (context.Context, *CalcRepository, *cachemock.MockCache) {
 	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
 	cache := new(cachemock.MockCache)
 
-	return context.Background(), NewCalcRepository(log, cache), cache
+	return context.Background(), NewCalcRepository(log, cache, clock.Fixed(cachedAt), Refresh{}, ""), cache
 }
 
 func TestNewCalcRepository(t *testing.T) {
 	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
 	cache := new(cachemock.MockCache)
-	repo := NewCalcRepository(log, cache)
+	repo := NewCalcRepository(log, cache, clock.System{}, Refresh{}, "")
 
 	require.NotEmpty(t, repo)
 }
 
 func TestGenerateKey(t *testing.T) {
-	cases := []struct {
-		in   *requests.CalculateRequest
-		want string
-	}{
-		{
-			&requests.CalculateRequest{
-				CalcParams: dto.CalcParams{
-					ObjectCost:     1,
-					InitialPayment: 2,
-					Months:         3,
-				},
-				Program: dto.CalcProgram{
-					Base: true,
-				},
-			},
-			"{\"object_cost\":1,\"initial_payment\":2,\"months\":3,\"program\":{\"base\":true}}",
-		},
-		{
-			&requests.CalculateRequest{
-				CalcParams: dto.CalcParams{},
-				Program:    dto.CalcProgram{},
+	in := &requests.CalculateRequest{
+		CalcParams: dto.CalcParams{
+			ObjectCost:     500000000,
+			InitialPayment: 100000000,
+			Months:         240,
+			StartDate:      "2024-06-18",
+			EarlyRepayments: []dto.EarlyRepayment{
+				{Month: 12, Amount: 10000000, Strategy: dto.StrategyReduceTerm},
+				{Month: 6, Amount: 5000000, Strategy: dto.StrategyReducePayment},
 			},
-			"{\"object_cost\":0,\"initial_payment\":0,\"months\":0,\"program\":{}}",
 		},
+		Program: dto.CalcProgram{ID: "salary"},
 	}
 
-	for _, tt := range cases {
-		res, err := generateKey(tt.in)
+	key, err := generateKey(in, "")
+	require.NoError(t, err)
+	require.Regexp(t, `^calc:v1:[0-9a-f]{64}$`, key)
+
+	// equal calculations have equal keys
+	equal := []*requests.CalculateRequest{
+		{CalcParams: in.CalcParams, Program: dto.CalcProgram{Salary: true}},
+		{CalcParams: in.CalcParams, Program: in.Program, Borrower: &dto.Borrower{MonthlyIncome: 30000000}},
+	}
+	withType := *in
+	withType.PaymentType = dto.PaymentTypeAnnuity
+	equal = append(equal, &withType)
+	reordered := *in
+	reordered.EarlyRepayments = []dto.EarlyRepayment{in.EarlyRepayments[1], in.EarlyRepayments[0]}
+	equal = append(equal, &reordered)
+
+	for _, tt := range equal {
+		res, err := generateKey(tt, "")
 		require.NoError(t, err)
-		require.Equal(t, tt.want, res)
+		require.Equal(t, key, res)
+	}
+
+	// different calculations have different keys
+	differentType := *in
+	differentType.PaymentType = dto.PaymentTypeDifferentiated
+	differentDate := *in
+	differentDate.StartDate = "2024-06-19"
+	different := []*requests.CalculateRequest{
+		{CalcParams: in.CalcParams, Program: dto.CalcProgram{ID: "base"}},
+		&differentType,
+		&differentDate,
 	}
+
+	for _, tt := range different {
+		res, err := generateKey(tt, "")
+		require.NoError(t, err)
+		require.NotEqual(t, key, res)
+	}
+
+	// results of another configuration are not read
+	res, err := generateKey(in, "3f2a9c1d0b7e4a65")
+	require.NoError(t, err)
+	require.NotEqual(t, key, res)
+}
+
+func envelopeOf(t *testing.T, in *requests.CalculateRequest, aggregates *dto.CalcAggregates) []byte {
+	t.Helper()
+
+	res, err := encodeEnvelope(in, aggregates, cachedAt)
+	require.NoError(t, err)
+
+	return res
 }
 
 func TestCalcRepository_Get(t *testing.T) {
@@ -69,17 +116,23 @@ func TestCalcRepository_Get(t *testing.T) {
 		Program:    dto.CalcProgram{},
 	}
 
-	marshalled, err := generateKey(in)
+	marshalled, err := generateKey(in, "")
 	require.NoError(t, err)
 
 	agg := &dto.CalcAggregates{
 		LastPaymentDate: "123",
-		Rate:            456,
+		PaymentType:     "annuity",
+		Currency:        money.Currency{Code: "RUB", MinorUnits: 2},
+		Rate:            4.56,
+		RateBps:         456,
 		LoanSum:         789,
 		MonthlyPayment:  10,
+		FirstPayment:    10,
+		LastPayment:     10,
+		MaxPayment:      10,
 		Overpayment:     11,
 	}
-	aggMarshalled := "{\"last_payment_date\":\"123\",\"rate\":456,\"loan_sum\":789,\"monthly_payment\":10,\"overpayment\":11}"
+	aggMarshalled := "{\"v\":1,\"request\":{\"object_cost\":0,\"initial_payment\":0,\"months\":0,\"program\":{}},\"aggregates\":{\"last_payment_date\":\"123\",\"payment_type\":\"annuity\",\"currency\":{\"code\":\"RUB\",\"minor_units\":2},\"rate\":4.56,\"rate_bps\":456,\"loan_sum\":789,\"monthly_payment\":10,\"first_payment\":10,\"last_payment\":10,\"max_payment\":10,\"overpayment\":11}}"
 	cache.On("Get", ctx, marshalled).Return([]byte(aggMarshalled), nil)
 
 	res, err := repo.Get(ctx, in)
@@ -97,7 +150,7 @@ func TestCalcRepository_Get_NonexistentKey(t *testing.T) {
 		Program:    dto.CalcProgram{},
 	}
 
-	marshalled, err := generateKey(in)
+	marshalled, err := generateKey(in, "")
 	require.NoError(t, err)
 
 	cache.On("Get", ctx, marshalled).Return(make([]byte, 0), cachepkg.ErrKeyNotExists)
@@ -116,20 +169,27 @@ func TestCalcRepository_Set(t *testing.T) {
 		CalcParams: dto.CalcParams{},
 		Program:    dto.CalcProgram{},
 	}
-	inMarshalled := "{\"object_cost\":0,\"initial_payment\":0,\"months\":0,\"program\":{}}"
+	inMarshalled, err := generateKey(in, "")
+	require.NoError(t, err)
 
 	agg := &dto.CalcAggregates{
 		LastPaymentDate: "123",
-		Rate:            456,
+		PaymentType:     "annuity",
+		Currency:        money.Currency{Code: "RUB", MinorUnits: 2},
+		Rate:            4.56,
+		RateBps:         456,
 		LoanSum:         789,
 		MonthlyPayment:  10,
+		FirstPayment:    10,
+		LastPayment:     10,
+		MaxPayment:      10,
 		Overpayment:     11,
 	}
-	aggMarshalled := "{\"last_payment_date\":\"123\",\"rate\":456,\"loan_sum\":789,\"monthly_payment\":10,\"overpayment\":11}"
+	aggMarshalled := "{\"v\":1,\"request\":{\"object_cost\":0,\"initial_payment\":0,\"months\":0,\"program\":{}},\"aggregates\":{\"last_payment_date\":\"123\",\"payment_type\":\"annuity\",\"currency\":{\"code\":\"RUB\",\"minor_units\":2},\"rate\":4.56,\"rate_bps\":456,\"loan_sum\":789,\"monthly_payment\":10,\"first_payment\":10,\"last_payment\":10,\"max_payment\":10,\"overpayment\":11},\"cached_at\":1718668800}"
 
 	cache.On("Set", ctx, inMarshalled, []byte(aggMarshalled)).Return(nil)
 
-	err := repo.Set(ctx, in, agg)
+	err = repo.Set(ctx, in, agg)
 	require.NoError(t, err)
 
 	cache.On("Get", ctx, inMarshalled).Return([]byte(aggMarshalled), nil)
@@ -145,8 +205,8 @@ func TestCalcRepository_List(t *testing.T) {
 
 	list := []*cachepkg.Entry{
 		{
-			Key: "{}",
-			Val: []byte("{}"),
+			Key: "calc:v1:0",
+			Val: envelopeOf(t, &requests.CalculateRequest{}, &dto.CalcAggregates{}),
 			ID:  0,
 		},
 	}
@@ -158,8 +218,408 @@ func TestCalcRepository_List(t *testing.T) {
 	require.NotEmpty(t, res)
 }
 
+func TestCalcRepository_List_StaleEntries(t *testing.T) {
+	ctx, repo, cache := setup()
+
+	in := &requests.CalculateRequest{CalcParams: dto.CalcParams{Months: 12}, Program: dto.CalcProgram{ID: "base"}}
+	list := []*cachepkg.Entry{
+		{ID: 1, Key: "{}", Val: []byte(`{"loan_sum":4000000}`)},
+		{ID: 2, Key: "calc:v1:0", Val: envelopeOf(t, in, &dto.CalcAggregates{LoanSum: 100})},
+	}
+
+	cache.On("List", ctx).Return(list, nil)
+
+	res, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []*dto.CacheEntry{
+		{ID: 2, Params: &in.CalcParams, Program: &in.Program, Aggregates: &dto.CalcAggregates{LoanSum: 100}},
+	}, res)
+}
+
+func TestCalcRepository_List_Metadata(t *testing.T) {
+	ctx, repo, cache := setup()
+
+	in := &requests.CalculateRequest{CalcParams: dto.CalcParams{Months: 12}}
+	expiresAt := cachedAt.Add(time.Hour)
+	list := []*cachepkg.Entry{{
+		ID:         1,
+		Key:        "calc:v1:0",
+		Val:        envelopeOf(t, in, &dto.CalcAggregates{}),
+		CreatedAt:  cachedAt,
+		ExpiresAt:  expiresAt,
+		Hits:       3,
+		LastAccess: cachedAt.Add(time.Minute),
+	}}
+
+	cache.On("List", ctx).Return(list, nil)
+
+	res, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, cachedAt, res[0].CreatedAt)
+	require.Equal(t, expiresAt, *res[0].ExpiresAt)
+	require.Equal(t, int64(3), res[0].Hits)
+	require.Equal(t, cachedAt.Add(time.Minute), *res[0].LastAccess)
+
+	// unknown times are omitted
+	list[0].ExpiresAt, list[0].LastAccess = time.Time{}, time.Time{}
+
+	res, err = repo.List(ctx)
+	require.NoError(t, err)
+	require.Nil(t, res[0].ExpiresAt)
+	require.Nil(t, res[0].LastAccess)
+}
+
+func TestCalcRepository_Get_StaleEntry(t *testing.T) {
+	ctx, repo, cache := setup()
+
+	in := &requests.CalculateRequest{}
+	key, err := generateKey(in, "")
+	require.NoError(t, err)
+
+	cache.On("Get", ctx, key).Return([]byte(`{"v":0,"loan_sum":4000000}`), nil)
+
+	_, err = repo.Get(ctx, in)
+	require.ErrorIs(t, err, errStaleEntry)
+}
+
 func TestCalcRepository_Clear(t *testing.T) {
 	ctx, repo, cache := setup()
 	cache.On("Clear", ctx).Return(nil)
 	repo.Clear(ctx)
 }
+
+func TestCalcRepository_GetByID(t *testing.T) {
+	ctx, repo, cache := setup()
+
+	in := &requests.CalculateRequest{
+		CalcParams: dto.CalcParams{ObjectCost: 5000000, InitialPayment: 1000000, Months: 240},
+		Program:    dto.CalcProgram{ID: "salary"},
+	}
+	key, err := generateKey(in, "")
+	require.NoError(t, err)
+
+	val := envelopeOf(t, in, &dto.CalcAggregates{LoanSum: 4000000})
+	cache.On("GetByID", ctx, int64(7)).Return(&cachepkg.Entry{ID: 7, Key: key, Val: val}, nil)
+	cache.On("GetByID", ctx, int64(8)).Return((*cachepkg.Entry)(nil), cachepkg.ErrKeyNotExists)
+
+	res, err := repo.GetByID(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, &dto.CacheEntry{
+		ID:         7,
+		Params:     &in.CalcParams,
+		Program:    &in.Program,
+		Aggregates: &dto.CalcAggregates{LoanSum: 4000000},
+	}, res)
+
+	_, err = repo.GetByID(ctx, 8)
+	require.ErrorIs(t, err, cachepkg.ErrKeyNotExists)
+
+	// entry of previous format is not found
+	cache.On("GetByID", ctx, int64(9)).Return(&cachepkg.Entry{ID: 9, Key: "{}", Val: []byte(`{"loan_sum":4000000}`)}, nil)
+
+	_, err = repo.GetByID(ctx, 9)
+	require.ErrorIs(t, err, cachepkg.ErrKeyNotExists)
+}
+
+func TestCalcRepository_Delete(t *testing.T) {
+	ctx, repo, cache := setup()
+
+	cache.On("Delete", ctx, int64(7)).Return(nil)
+	cache.On("Delete", ctx, int64(8)).Return(cachepkg.ErrKeyNotExists)
+
+	require.NoError(t, repo.Delete(ctx, 7))
+	require.ErrorIs(t, repo.Delete(ctx, 8), cachepkg.ErrKeyNotExists)
+}
+
+func TestCalcRepository_Purge(t *testing.T) {
+	ctx, repo, cache := setup()
+
+	cache.On("Purge", ctx).Return(3, nil)
+
+	n, err := repo.Purge(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+}
+
+func TestCalcRepository_Stats(t *testing.T) {
+	ctx, repo, cache := setup()
+
+	salary := &requests.CalculateRequest{
+		CalcParams: dto.CalcParams{ObjectCost: 5000000, InitialPayment: 1000000, Months: 240},
+		Program:    dto.CalcProgram{ID: "salary"},
+	}
+	base := &requests.CalculateRequest{
+		CalcParams: dto.CalcParams{ObjectCost: 5000000, InitialPayment: 1000000, Months: 120},
+		Program:    dto.CalcProgram{Base: true},
+	}
+	invalid := &requests.CalculateRequest{
+		CalcParams: dto.CalcParams{ObjectCost: 5000000, InitialPayment: 1000000, Months: 100000},
+		Program:    dto.CalcProgram{ID: "unknown"},
+	}
+
+	salaryKey, err := generateKey(salary, "")
+	require.NoError(t, err)
+	baseKey, err := generateKey(base, "")
+	require.NoError(t, err)
+	invalidKey, err := generateKey(invalid, "")
+	require.NoError(t, err)
+
+	cache.On("Get", ctx, salaryKey).Return(envelopeOf(t, salary, &dto.CalcAggregates{}), nil)
+	cache.On("Get", mock.Anything, baseKey).Return(make([]byte, 0), cachepkg.ErrKeyNotExists)
+	cache.On("Get", mock.Anything, invalidKey).Return(make([]byte, 0), cachepkg.ErrKeyNotExists)
+	cache.On("Set", mock.Anything, baseKey, mock.Anything).Return(nil)
+	cache.On("List", ctx).Return([]*cachepkg.Entry{{Key: salaryKey}, {Key: baseKey}}, nil)
+
+	for range 2 {
+		_, err = repo.Get(ctx, salary)
+		require.NoError(t, err)
+	}
+	_, err = repo.GetOrCompute(ctx, base, func(context.Context) (*dto.CalcAggregates, error) {
+		return &dto.CalcAggregates{}, nil
+	})
+	require.NoError(t, err)
+
+	// failed calculation is not counted
+	_, err = repo.GetOrCompute(ctx, invalid, func(context.Context) (*dto.CalcAggregates, error) {
+		return nil, errors.New("unknown program")
+	})
+	require.Error(t, err)
+
+	res, err := repo.Stats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), res.Hits)
+	require.Equal(t, int64(1), res.Misses)
+	require.Equal(t, int64(1), res.Sets)
+	require.Equal(t, 2, res.Entries)
+	require.Equal(t, map[string]int64{"salary": 2, "base": 1}, res.Programs)
+	require.Equal(t, map[int]int64{240: 2, 120: 1}, res.Terms)
+	require.Equal(t, []*dto.RequestCount{
+		{Params: &salary.CalcParams, Program: &salary.Program, Count: 2},
+		{Params: &base.CalcParams, Program: &base.Program, Count: 1},
+	}, res.TopRequests)
+}
+
+func TestCalcRepository_Stats_CacheStats(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	ctx := context.Background()
+	repo := NewCalcRepository(log, memory.New(log, 100, memory.Limits{MaxEntries: 1}, clock.System{}), clock.System{}, Refresh{}, "")
+
+	for months := range 3 {
+		in := &requests.CalculateRequest{CalcParams: dto.CalcParams{Months: months + 1}}
+		require.NoError(t, repo.Set(ctx, in, &dto.CalcAggregates{}))
+	}
+
+	res, err := repo.Stats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Entries)
+	require.Equal(t, int64(2), res.Evictions)
+	require.Equal(t, int64(3), res.Sets)
+}
+
+// movingClock is a clock which is moved forward by tests.
+type movingClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *movingClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *movingClock) add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestCalcRepository_GetOrCompute_Coalesces(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	ctx := context.Background()
+	repo := NewCalcRepository(log, memory.New(log, 100, memory.Limits{}, clock.System{}), clock.System{}, Refresh{}, "")
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	compute := func(context.Context) (*dto.CalcAggregates, error) {
+		calls.Add(1)
+		<-release
+		return &dto.CalcAggregates{LoanSum: 100}, nil
+	}
+
+	in := &requests.CalculateRequest{CalcParams: dto.CalcParams{Months: 12}}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := repo.GetOrCompute(ctx, in, compute)
+			require.NoError(t, err)
+			require.Equal(t, money.Amount(100), res.LoanSum)
+		}()
+	}
+
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), calls.Load())
+
+	stats, err := repo.Stats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), stats.Sets)
+	require.Equal(t, int64(10), stats.Hits+stats.Misses)
+}
+
+func TestCalcRepository_GetOrCompute_Error(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	ctx := context.Background()
+	cache := memory.New(log, 100, memory.Limits{}, clock.System{})
+	repo := NewCalcRepository(log, cache, clock.System{}, Refresh{}, "")
+
+	want := errors.New("failed to calculate")
+	_, err := repo.GetOrCompute(ctx, &requests.CalculateRequest{}, func(context.Context) (*dto.CalcAggregates, error) {
+		return nil, want
+	})
+	require.ErrorIs(t, err, want)
+
+	items, err := cache.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, items)
+}
+
+func TestCalcRepository_GetOrCompute_RefreshAhead(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	ctx := context.Background()
+	clk := &movingClock{now: cachedAt}
+	repo := NewCalcRepository(log, memory.New(log, 100, memory.Limits{}, clk), clk, Refresh{
+		TTL:   100 * time.Second,
+		Ahead: 10 * time.Second,
+	}, "")
+
+	var calls atomic.Int32
+	compute := func(context.Context) (*dto.CalcAggregates, error) {
+		return &dto.CalcAggregates{LoanSum: money.Amount(calls.Add(1))}, nil
+	}
+
+	in := &requests.CalculateRequest{CalcParams: dto.CalcParams{Months: 12}}
+
+	res, err := repo.GetOrCompute(ctx, in, compute)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(1), res.LoanSum)
+
+	// entry is not refreshed before refresh window
+	clk.add(80 * time.Second)
+	res, err = repo.GetOrCompute(ctx, in, compute)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(1), res.LoanSum)
+	require.Equal(t, int32(1), calls.Load())
+
+	// cached result is returned and refreshed in background
+	clk.add(15 * time.Second)
+	res, err = repo.GetOrCompute(ctx, in, compute)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(1), res.LoanSum)
+
+	require.NoError(t, repo.Wait(ctx))
+
+	res, err = repo.GetOrCompute(ctx, in, compute)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(2), res.LoanSum)
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestCalcRepository_Wait(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	ctx := context.Background()
+	clk := &movingClock{now: cachedAt}
+	repo := NewCalcRepository(log, memory.New(log, 100, memory.Limits{}, clk), clk, Refresh{
+		TTL:   100 * time.Second,
+		Ahead: 10 * time.Second,
+	}, "")
+
+	in := &requests.CalculateRequest{CalcParams: dto.CalcParams{Months: 12}}
+	_, err := repo.GetOrCompute(ctx, in, func(context.Context) (*dto.CalcAggregates, error) {
+		return &dto.CalcAggregates{}, nil
+	})
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	clk.add(95 * time.Second)
+	_, err = repo.GetOrCompute(ctx, in, func(context.Context) (*dto.CalcAggregates, error) {
+		<-release
+		return &dto.CalcAggregates{}, nil
+	})
+	require.NoError(t, err)
+
+	// refresh is still running
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, repo.Wait(timeout), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, repo.Wait(ctx))
+}
+
+func TestCalcRepository_Collect(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	ctx := context.Background()
+	repo := NewCalcRepository(log, memory.New(log, 100, memory.Limits{}, clock.System{}), clock.System{}, Refresh{}, "")
+
+	in := &requests.CalculateRequest{CalcParams: dto.CalcParams{Months: 12}, Program: dto.CalcProgram{ID: "salary"}}
+	compute := func(context.Context) (*dto.CalcAggregates, error) { return &dto.CalcAggregates{}, nil }
+
+	for range 2 {
+		_, err := repo.GetOrCompute(ctx, in, compute)
+		require.NoError(t, err)
+	}
+
+	// program removed from configuration after its result was cached
+	_, err := repo.GetOrCompute(ctx, &requests.CalculateRequest{Program: dto.CalcProgram{ID: "removed"}}, compute)
+	require.NoError(t, err)
+
+	families, err := NewCollector(repo, []string{"salary", "base"}).Collect(ctx)
+	require.NoError(t, err)
+
+	values := make(map[string]float64)
+	for _, f := range families {
+		for _, s := range f.Samples {
+			name := f.Name
+			for _, l := range s.Labels {
+				name += "/" + l.Value
+			}
+			values[name] = s.Value
+		}
+	}
+
+	require.Equal(t, float64(1), values["mortgage_cache_hits_total"])
+	require.Equal(t, float64(2), values["mortgage_cache_misses_total"])
+	require.Equal(t, float64(2), values["mortgage_cache_sets_total"])
+	require.Equal(t, float64(2), values["mortgage_cache_entries"])
+	require.Equal(t, float64(2), values["mortgage_calculation_requests_total/salary"])
+	require.Equal(t, float64(0), values["mortgage_calculation_requests_total/base"])
+	require.Equal(t, float64(1), values["mortgage_calculation_requests_total/unknown"])
+	require.NotContains(t, values, "mortgage_calculation_requests_total/removed")
+}
+
+func TestUsage_Request_SpaceSaving(t *testing.T) {
+	u := newUsage()
+
+	in := &requests.CalculateRequest{CalcParams: dto.CalcParams{Months: 12}, Program: dto.CalcProgram{ID: "base"}}
+
+	for i := range maxTrackedRequests {
+		u.request(fmt.Sprintf("key%d", i), in)
+	}
+	u.request("key0", in)
+	u.request("new", in)
+
+	res := u.snapshot()
+	require.Len(t, res.requests, maxTrackedRequests)
+	require.Equal(t, keyCount{key: "key0", count: 2, request: *in}, res.requests[0])
+	require.Equal(t, keyCount{key: "new", count: 2, request: *in}, res.requests[1])
+	require.Equal(t, int64(maxTrackedRequests+2), res.programs["base"])
+}
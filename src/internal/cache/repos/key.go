@@ -0,0 +1,109 @@
+package cacherepos
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/domain/dto/requests"
+	"mortgage-calculator/src/internal/lib/money"
+	"sort"
+	"strings"
+	"time"
+)
+
+// keyVersion is a version of key scheme and value envelope.
+// It must be incremented whenever either format changes incompatibly, so entries of previous format are never read.
+const keyVersion = 1
+
+// canonicalRequest is a normalized calculation request, requests of equal calculations have equal canonical form.
+type canonicalRequest struct {
+	Fingerprint     string               `json:"fingerprint"` // Fingerprint identifies configuration of calculations.
+	Program         string               `json:"program"`
+	ObjectCost      money.Amount         `json:"object_cost"`
+	InitialPayment  money.Amount         `json:"initial_payment"`
+	Months          int                  `json:"months"`
+	PaymentType     string               `json:"payment_type"`
+	StartDate       string               `json:"start_date"`
+	EarlyRepayments []dto.EarlyRepayment `json:"early_repayments"`
+}
+
+// envelope is a cached value, it keeps request, so entries are listed without parsing keys.
+type envelope struct {
+	Version    int                        `json:"v"`
+	Request    *requests.CalculateRequest `json:"request"`
+	Aggregates *dto.CalcAggregates        `json:"aggregates"`
+	CachedAt   int64                      `json:"cached_at,omitempty"` // CachedAt is unix time of caching.
+}
+
+// generateKey returns versioned hash of canonical request calculated with configuration of given fingerprint.
+func generateKey(in *requests.CalculateRequest, fingerprint string) (string, error) {
+	req := canonical(in)
+	req.Fingerprint = fingerprint
+
+	byteArr, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	return fmt.Sprintf("calc:v%d:%x", keyVersion, sha256.Sum256(byteArr)), nil
+}
+
+// keyHash returns hash part of key, it identifies request without exposing key scheme.
+func keyHash(key string) string {
+	return key[strings.LastIndexByte(key, ':')+1:]
+}
+
+// canonical normalizes request: program is chosen by id, default payment type is explicit
+// and early repayments are ordered, so their order doesn't affect the key.
+func canonical(in *requests.CalculateRequest) canonicalRequest {
+	paymentType := in.PaymentType
+	if paymentType == "" {
+		paymentType = dto.PaymentTypeAnnuity
+	}
+
+	repayments := make([]dto.EarlyRepayment, len(in.EarlyRepayments))
+	copy(repayments, in.EarlyRepayments)
+	sort.Slice(repayments, func(i, j int) bool {
+		a, b := repayments[i], repayments[j]
+		if a.Month != b.Month {
+			return a.Month < b.Month
+		}
+		if a.Strategy != b.Strategy {
+			return a.Strategy < b.Strategy
+		}
+		return a.Amount < b.Amount
+	})
+
+	return canonicalRequest{
+		Program:         in.Program.Key(),
+		ObjectCost:      in.ObjectCost,
+		InitialPayment:  in.InitialPayment,
+		Months:          in.Months,
+		PaymentType:     paymentType,
+		StartDate:       in.StartDate,
+		EarlyRepayments: repayments,
+	}
+}
+
+func encodeEnvelope(in *requests.CalculateRequest, aggregates *dto.CalcAggregates, cachedAt time.Time) ([]byte, error) {
+	return json.Marshal(envelope{ //nolint:wrapcheck // wrapped by caller
+		Version:    keyVersion,
+		Request:    in,
+		Aggregates: aggregates,
+		CachedAt:   cachedAt.Unix(),
+	})
+}
+
+func decodeEnvelope(data []byte) (*envelope, error) {
+	var res envelope
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal entry: %w", err)
+	}
+
+	if res.Version != keyVersion || res.Request == nil || res.Aggregates == nil {
+		return nil, fmt.Errorf("%w: version %d", errStaleEntry, res.Version)
+	}
+
+	return &res, nil
+}
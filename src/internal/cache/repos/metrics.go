@@ -0,0 +1,85 @@
+package cacherepos
+
+import (
+	"context"
+	"fmt"
+	"mortgage-calculator/src/internal/lib/metrics"
+	"slices"
+)
+
+// unknownProgram labels requests of programs missing in configuration.
+const unknownProgram = "unknown"
+
+// Collector exposes cache usage counters, cache size and requests by program as metrics.
+// Requests are labelled by configured programs only, so number of series doesn't depend on requests.
+type Collector struct {
+	repo     *CalcRepository
+	programs []string
+}
+
+// NewCollector is a constructor for Collector, programs are ids of configured programs.
+func NewCollector(repo *CalcRepository, programs []string) *Collector {
+	ids := slices.Clone(programs)
+	slices.Sort(ids)
+
+	return &Collector{repo: repo, programs: ids}
+}
+
+// Collect returns metrics of repository.
+func (c *Collector) Collect(ctx context.Context) ([]*metrics.Family, error) {
+	const op = "cacherepos.Collector.Collect"
+
+	stats, err := c.repo.Stats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	counter := func(name, help string, v int64) *metrics.Family {
+		return &metrics.Family{
+			Name:    name,
+			Help:    help,
+			Type:    metrics.TypeCounter,
+			Samples: []metrics.Sample{{Value: float64(v)}},
+		}
+	}
+
+	programs := &metrics.Family{
+		Name: "mortgage_calculation_requests_total",
+		Help: "Number of calculation requests by program, including ones served from cache.",
+		Type: metrics.TypeCounter,
+	}
+
+	unknown := int64(0)
+	for id, count := range stats.Programs {
+		if _, ok := slices.BinarySearch(c.programs, id); !ok {
+			unknown += count
+		}
+	}
+
+	for _, id := range c.programs {
+		programs.Samples = append(programs.Samples, metrics.Sample{
+			Labels: []metrics.Label{{Name: "program", Value: id}},
+			Value:  float64(stats.Programs[id]),
+		})
+	}
+	programs.Samples = append(programs.Samples, metrics.Sample{
+		Labels: []metrics.Label{{Name: "program", Value: unknownProgram}},
+		Value:  float64(unknown),
+	})
+
+	return []*metrics.Family{
+		counter("mortgage_cache_hits_total", "Number of calculations served from cache.", stats.Hits),
+		counter("mortgage_cache_misses_total", "Number of calculations missing in cache.", stats.Misses),
+		counter("mortgage_cache_sets_total", "Number of calculations saved to cache.", stats.Sets),
+		counter("mortgage_cache_coalesced_total", "Number of misses served by calculation of concurrent request.", stats.Coalesced),
+		counter("mortgage_cache_evictions_total", "Number of entries evicted to satisfy cache limits.", stats.Evictions),
+		counter("mortgage_cache_expirations_total", "Number of expired entries removed from cache.", stats.Expirations),
+		{
+			Name:    "mortgage_cache_entries",
+			Help:    "Number of entries in cache.",
+			Type:    metrics.TypeGauge,
+			Samples: []metrics.Sample{{Value: float64(stats.Entries)}},
+		},
+		programs,
+	}, nil
+}
@@ -0,0 +1,143 @@
+package cacherepos
+
+import (
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/domain/dto/requests"
+	"sort"
+	"sync"
+)
+
+// maxTrackedRequests limits number of distinct requests counted for top list.
+const maxTrackedRequests = 1000
+
+// usage counts cache requests.
+// The most requested keys are tracked by space-saving algorithm: when limit is reached,
+// the least requested key is replaced by the new one inheriting its count.
+type usage struct {
+	mu        sync.Mutex
+	hits      int64
+	misses    int64
+	sets      int64
+	coalesced int64 // coalesced counts misses served by computation of another request
+	requests  map[string]*keyCount
+	programs  map[string]int64
+	terms     map[int]int64
+}
+
+type usageSnapshot struct {
+	hits      int64
+	misses    int64
+	sets      int64
+	coalesced int64
+	requests  []keyCount // requests are sorted by count in descending order
+	programs  map[string]int64
+	terms     map[int]int64
+}
+
+type keyCount struct {
+	key     string
+	count   int64
+	request requests.CalculateRequest
+}
+
+func newUsage() *usage {
+	return &usage{
+		requests: make(map[string]*keyCount),
+		programs: make(map[string]int64),
+		terms:    make(map[int]int64),
+	}
+}
+
+// request counts request served from cache or calculated successfully,
+// so programs and terms are valid ones, terms out of range are skipped anyway to keep the map bounded.
+func (u *usage) request(key string, in *requests.CalculateRequest) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.programs[in.Program.Key()]++
+	if in.Months > 0 && in.Months <= dto.MaxMonths {
+		u.terms[in.Months]++
+	}
+
+	if kc, ok := u.requests[key]; ok {
+		kc.count++
+		return
+	}
+
+	var count int64
+	if len(u.requests) >= maxTrackedRequests {
+		var least *keyCount
+		for _, kc := range u.requests {
+			if least == nil || kc.count < least.count {
+				least = kc
+			}
+		}
+
+		delete(u.requests, least.key)
+		count = least.count
+	}
+
+	u.requests[key] = &keyCount{key: key, count: count + 1, request: *in}
+}
+
+func (u *usage) hit() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.hits++
+}
+
+func (u *usage) miss() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.misses++
+}
+
+func (u *usage) set() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.sets++
+}
+
+func (u *usage) coalesce() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.coalesced++
+}
+
+func (u *usage) snapshot() usageSnapshot {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	res := usageSnapshot{
+		hits:      u.hits,
+		misses:    u.misses,
+		sets:      u.sets,
+		coalesced: u.coalesced,
+		requests:  make([]keyCount, 0, len(u.requests)),
+		programs:  make(map[string]int64, len(u.programs)),
+		terms:     make(map[int]int64, len(u.terms)),
+	}
+
+	for _, kc := range u.requests {
+		res.requests = append(res.requests, *kc)
+	}
+	for k, c := range u.programs {
+		res.programs[k] = c
+	}
+	for k, c := range u.terms {
+		res.terms[k] = c
+	}
+
+	sort.Slice(res.requests, func(i, j int) bool {
+		if res.requests[i].count != res.requests[j].count {
+			return res.requests[i].count > res.requests[j].count
+		}
+		return res.requests[i].key < res.requests[j].key
+	})
+
+	return res
+}
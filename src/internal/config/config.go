@@ -6,23 +6,126 @@ import (
 	"flag"
 	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
+	"mortgage-calculator/src/internal/cache/memory"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/money"
+	"net/url"
 	"os"
 )
 
 var errFileNotExists = errors.New("config file does not exist")
 var errBadConfigFile = errors.New("unable to read config file")
+var errBadProgram = errors.New("invalid program configuration")
+var errBadMoney = errors.New("invalid money configuration")
+var errBadCache = errors.New("invalid cache configuration")
+var errBadHTTP = errors.New("invalid http configuration")
+var errBadTracing = errors.New("invalid tracing configuration")
+
+// Cache drivers.
+const (
+	CacheDriverMemory = "memory"
+	CacheDriverRedis  = "redis"
+	CacheDriverFile   = "file"
+)
+
+// Tracing exporters.
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterFile   = "file"
+	TracingExporterOTLP   = "otlp"
+)
+
+// maxMinorUnits limits digits of currency minor unit.
+const maxMinorUnits = 4
 
 // Config represents main app configuration.
 type Config struct {
-	Env   string `yaml:"env"`
-	Port  int    `yaml:"port"`
-	Cache Cache  `yaml:"cache"`
+	Env      string    `yaml:"env"`
+	Port     int       `yaml:"port"`
+	HTTP     HTTP      `yaml:"http"`
+	Cache    Cache     `yaml:"cache"`
+	Money    Money     `yaml:"money"`
+	Tracing  Tracing   `yaml:"tracing"`
+	Programs []Program `yaml:"programs,omitempty"` // Programs overrides default programs when not empty.
+}
+
+// HTTP represents timeouts of http server in seconds, zero value of any timeout means its default.
+type HTTP struct {
+	ReadTimeout     int `yaml:"read_timeout"`
+	WriteTimeout    int `yaml:"write_timeout"`
+	IdleTimeout     int `yaml:"idle_timeout"`
+	ShutdownTimeout int `yaml:"shutdown_timeout"` // ShutdownTimeout limits stop including draining of in-flight requests.
+}
+
+// Tracing represents configuration of request tracing.
+type Tracing struct {
+	Exporter string `yaml:"exporter"` // Exporter is one of none (default), stdout, file or otlp.
+	Path     string `yaml:"path"`     // Path to file of file exporter, it is created when doesn't exist.
+	// Endpoint is url of OTLP/HTTP traces receiver of otlp exporter, e.g. http://localhost:4318/v1/traces.
+	Endpoint string            `yaml:"endpoint"`
+	Headers  map[string]string `yaml:"headers,omitempty"` // Headers are added to every export request.
+	Timeout  int               `yaml:"timeout"`           // Timeout of export request in seconds.
+	Service  string            `yaml:"service"`           // Service is a name of service in traces.
+	// SampleRatio is a fraction of traces started by service which are exported, zero value means all traces.
+	SampleRatio float64 `yaml:"sample_ratio"`
 }
 
 // Cache represents cache configuration.
 type Cache struct {
 	TTL   int `yaml:"ttl"`
-	Clear int `yaml:"clear"` // Clear sets interval to clean expired cache entries.
+	Clear int `yaml:"clear"` // Clear sets interval to clean expired cache entries, zero value disables cleaning.
+	// ClearJitter sets upper bound of random delay added to every cleaning interval.
+	ClearJitter int `yaml:"clear_jitter"`
+	// RefreshAhead sets time before expiration since which requested entry is recalculated in background,
+	// zero value disables refreshing.
+	RefreshAhead int    `yaml:"refresh_ahead"`
+	Driver       string `yaml:"driver"` // Driver is one of memory (default), redis or file.
+	Redis        Redis  `yaml:"redis"`
+	File         File   `yaml:"file"`
+
+	// Limits of memory driver, zero value means that the limit is not applied.
+	MaxEntries int    `yaml:"max_entries"`
+	MaxBytes   int64  `yaml:"max_bytes"`
+	Eviction   string `yaml:"eviction"` // Eviction is one of lru (default) or lfu.
+}
+
+// Redis represents configuration of redis cache driver.
+type Redis struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password" env:"REDIS_PASSWORD"`
+	DB       int    `yaml:"db"`
+	Prefix   string `yaml:"prefix"`    // Prefix is prepended to every key.
+	PoolSize int    `yaml:"pool_size"` // PoolSize limits number of idle connections.
+	Timeout  int    `yaml:"timeout"`   // Timeout of a command in seconds.
+}
+
+// Money represents configuration of monetary amounts.
+// Zero value means default currency and rounding of calculator.
+type Money struct {
+	Currency   string `yaml:"currency"`    // Currency is currency code, e.g. RUB.
+	MinorUnits int    `yaml:"minor_units"` // MinorUnits is digits count of minor unit, all amounts are given in minor units.
+	Rounding   string `yaml:"rounding"`    // Rounding is one of up, down, half_up, half_even.
+}
+
+// Program represents mortgage program configuration.
+// Zero value of any limit means that the limit is not applied.
+type Program struct {
+	ID                     string  `yaml:"id"`
+	Name                   string  `yaml:"name"`
+	Rate                   float64 `yaml:"rate"` // Rate is annual interest rate, e.g. 0.08 for 8%.
+	MinInitialPaymentRatio float64 `yaml:"min_initial_payment_ratio"`
+	MinMonths              int     `yaml:"min_months"`
+	MaxMonths              int     `yaml:"max_months"`
+	MinLoan                int     `yaml:"min_loan"`
+	MaxLoan                int     `yaml:"max_loan"`
+	MaxObjectCost          int     `yaml:"max_object_cost"`
+	MaxDebtToIncome        float64 `yaml:"max_debt_to_income"`
+}
+
+// File represents configuration of file cache driver.
+type File struct {
+	Path string `yaml:"path"` // Path to cache log file, it is created when doesn't exist.
 }
 
 // LoadPath loads configuration from specified path and returns config instance and error.
@@ -38,9 +141,156 @@ func LoadPath(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("%w: %s", errBadConfigFile, err.Error())
 	}
 
+	if err := validatePrograms(cfg.Programs); err != nil {
+		return nil, err
+	}
+
+	if err := validateMoney(cfg.Money); err != nil {
+		return nil, err
+	}
+
+	if err := validateCache(cfg.Cache); err != nil {
+		return nil, err
+	}
+
+	if err := validateHTTP(cfg.HTTP); err != nil {
+		return nil, err
+	}
+
+	if err := validateTracing(cfg.Tracing); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
+// validatePrograms checks that every program has unique id, positive rate, consistent limits
+// with term within dto.MaxMonths, and valid ratios.
+func validatePrograms(programs []Program) error {
+	ids := make(map[string]struct{}, len(programs))
+
+	for _, p := range programs {
+		if p.ID == "" {
+			return fmt.Errorf("%w: empty id", errBadProgram)
+		}
+		if _, ok := ids[p.ID]; ok {
+			return fmt.Errorf("%w: duplicate id %s", errBadProgram, p.ID)
+		}
+		if p.Rate <= 0 {
+			return fmt.Errorf("%w: non-positive rate of %s", errBadProgram, p.ID)
+		}
+		if p.MinMonths < 0 || p.MaxMonths < 0 || p.MinMonths > dto.MaxMonths || p.MaxMonths > dto.MaxMonths {
+			return fmt.Errorf("%w: term of %s should be within %d months", errBadProgram, p.ID, dto.MaxMonths)
+		}
+		if p.MaxMonths > 0 && p.MinMonths > p.MaxMonths {
+			return fmt.Errorf("%w: minimal term of %s exceeds maximal one", errBadProgram, p.ID)
+		}
+		if p.MinLoan < 0 || p.MaxLoan < 0 || p.MaxObjectCost < 0 {
+			return fmt.Errorf("%w: negative amount limit of %s", errBadProgram, p.ID)
+		}
+		if p.MaxLoan > 0 && p.MinLoan > p.MaxLoan {
+			return fmt.Errorf("%w: minimal loan sum of %s exceeds maximal one", errBadProgram, p.ID)
+		}
+		if p.MinInitialPaymentRatio < 0 || p.MinInitialPaymentRatio > 1 {
+			return fmt.Errorf("%w: initial payment ratio of %s is out of range", errBadProgram, p.ID)
+		}
+		if p.MaxDebtToIncome < 0 || p.MaxDebtToIncome > 1 {
+			return fmt.Errorf("%w: debt-to-income threshold of %s is out of range", errBadProgram, p.ID)
+		}
+
+		ids[p.ID] = struct{}{}
+	}
+
+	return nil
+}
+
+// validateMoney checks that rounding mode is supported and minor units are within limits.
+func validateMoney(m Money) error {
+	if m.MinorUnits < 0 || m.MinorUnits > maxMinorUnits {
+		return fmt.Errorf("%w: minor units should be from 0 to %d", errBadMoney, maxMinorUnits)
+	}
+
+	if m.Rounding != "" {
+		if _, err := money.ParseRounding(m.Rounding); err != nil {
+			return fmt.Errorf("%w: %w", errBadMoney, err)
+		}
+	}
+
+	return nil
+}
+
+// validateHTTP checks that timeouts are not negative.
+func validateHTTP(h HTTP) error {
+	if h.ReadTimeout < 0 || h.WriteTimeout < 0 || h.IdleTimeout < 0 || h.ShutdownTimeout < 0 {
+		return fmt.Errorf("%w: negative timeout", errBadHTTP)
+	}
+
+	return nil
+}
+
+// validateTracing checks that exporter is supported and has required settings.
+func validateTracing(t Tracing) error {
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		return fmt.Errorf("%w: sample ratio should be from 0 to 1", errBadTracing)
+	}
+
+	if t.Timeout < 0 {
+		return fmt.Errorf("%w: negative timeout", errBadTracing)
+	}
+
+	switch t.Exporter {
+	case "", TracingExporterNone, TracingExporterStdout:
+	case TracingExporterFile:
+		if t.Path == "" {
+			return fmt.Errorf("%w: empty file path", errBadTracing)
+		}
+	case TracingExporterOTLP:
+		u, err := url.Parse(t.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: endpoint should be http or https url", errBadTracing)
+		}
+	default:
+		return fmt.Errorf("%w: unknown exporter %s", errBadTracing, t.Exporter)
+	}
+
+	return nil
+}
+
+// validateCache checks that cache driver is supported and has required settings.
+func validateCache(c Cache) error {
+	if c.MaxEntries < 0 || c.MaxBytes < 0 {
+		return fmt.Errorf("%w: negative size limit", errBadCache)
+	}
+
+	if c.Clear < 0 || c.ClearJitter < 0 {
+		return fmt.Errorf("%w: negative cleaning interval", errBadCache)
+	}
+
+	if c.RefreshAhead < 0 || (c.RefreshAhead > 0 && c.RefreshAhead >= c.TTL) {
+		return fmt.Errorf("%w: refresh ahead must be less than ttl", errBadCache)
+	}
+
+	if _, err := memory.ParsePolicy(c.Eviction); err != nil {
+		return fmt.Errorf("%w: %w", errBadCache, err)
+	}
+
+	switch c.Driver {
+	case "", CacheDriverMemory:
+	case CacheDriverRedis:
+		if c.Redis.Addr == "" {
+			return fmt.Errorf("%w: empty redis address", errBadCache)
+		}
+	case CacheDriverFile:
+		if c.File.Path == "" {
+			return fmt.Errorf("%w: empty file path", errBadCache)
+		}
+	default:
+		return fmt.Errorf("%w: unknown driver %s", errBadCache, c.Driver)
+	}
+
+	return nil
+}
+
 // MustLoad fetches path, loads configuration and panics on any error.
 func MustLoad() *Config {
 	configPath := fetchConfigPath()
@@ -51,6 +51,220 @@ func TestLoadPath(t *testing.T) {
 	require.Empty(t, res)
 }
 
+func TestLoadPath_Programs(t *testing.T) {
+	cfg := &Config{
+		Env:  "local",
+		Port: 8080,
+		Programs: []Program{
+			{
+				ID:                     "family",
+				Name:                   "Family",
+				Rate:                   0.06,
+				MinInitialPaymentRatio: 0.15,
+				MinMonths:              12,
+				MaxMonths:              360,
+				MinLoan:                100000,
+				MaxLoan:                6000000,
+				MaxDebtToIncome:        0.4,
+			},
+		},
+	}
+
+	file, cleanup := setup(t, cfg)
+	defer cleanup()
+
+	res, err := LoadPath(file.Name())
+	require.NoError(t, err)
+	require.Equal(t, *cfg, *res)
+}
+
+func TestLoadPath_BadPrograms(t *testing.T) {
+	cases := [][]Program{
+		{{ID: "", Rate: 0.1}},
+		{{ID: "base", Rate: 0.1}, {ID: "base", Rate: 0.2}},
+		{{ID: "base", Rate: 0}},
+		{{ID: "base", Rate: 0.1, MaxDebtToIncome: 1.5}},
+		{{ID: "base", Rate: 0.1, MaxMonths: 1201}},
+		{{ID: "base", Rate: 0.1, MinMonths: 240, MaxMonths: 120}},
+		{{ID: "base", Rate: 0.1, MinLoan: 2000000, MaxLoan: 1000000}},
+		{{ID: "base", Rate: 0.1, MaxLoan: -1}},
+		{{ID: "base", Rate: 0.1, MinInitialPaymentRatio: 1.2}},
+		{{ID: "base", Rate: 0.1, MinInitialPaymentRatio: -0.1}},
+	}
+
+	for _, programs := range cases {
+		file, cleanup := setup(t, &Config{Programs: programs})
+
+		res, err := LoadPath(file.Name())
+		require.Error(t, err)
+		require.ErrorIs(t, err, errBadProgram)
+		require.Empty(t, res)
+
+		cleanup()
+	}
+}
+
+func TestLoadPath_Money(t *testing.T) {
+	cfg := &Config{
+		Env:   "local",
+		Port:  8080,
+		Money: Money{Currency: "USD", MinorUnits: 2, Rounding: "half_even"},
+	}
+
+	file, cleanup := setup(t, cfg)
+	defer cleanup()
+
+	res, err := LoadPath(file.Name())
+	require.NoError(t, err)
+	require.Equal(t, *cfg, *res)
+}
+
+func TestLoadPath_BadMoney(t *testing.T) {
+	cases := []Money{
+		{Currency: "RUB", MinorUnits: -1},
+		{Currency: "RUB", MinorUnits: 5},
+		{Currency: "RUB", MinorUnits: 2, Rounding: "ceil"},
+	}
+
+	for _, m := range cases {
+		file, cleanup := setup(t, &Config{Money: m})
+
+		res, err := LoadPath(file.Name())
+		require.Error(t, err)
+		require.ErrorIs(t, err, errBadMoney)
+		require.Empty(t, res)
+
+		cleanup()
+	}
+}
+
+func TestLoadPath_RedisCache(t *testing.T) {
+	cfg := &Config{
+		Env:  "local",
+		Port: 8080,
+		Cache: Cache{
+			TTL:    100,
+			Driver: CacheDriverRedis,
+			Redis:  Redis{Addr: "localhost:6379", DB: 1, Prefix: "mortgage:", PoolSize: 5, Timeout: 3},
+		},
+	}
+
+	file, cleanup := setup(t, cfg)
+	defer cleanup()
+
+	res, err := LoadPath(file.Name())
+	require.NoError(t, err)
+	require.Equal(t, *cfg, *res)
+}
+
+func TestLoadPath_FileCache(t *testing.T) {
+	cfg := &Config{
+		Env:   "local",
+		Port:  8080,
+		Cache: Cache{TTL: 100, Clear: 100, Driver: CacheDriverFile, File: File{Path: "/var/lib/mortgage/cache.log"}},
+	}
+
+	file, cleanup := setup(t, cfg)
+	defer cleanup()
+
+	res, err := LoadPath(file.Name())
+	require.NoError(t, err)
+	require.Equal(t, *cfg, *res)
+}
+
+func TestLoadPath_CacheLimits(t *testing.T) {
+	cfg := &Config{
+		Env:   "local",
+		Port:  8080,
+		Cache: Cache{TTL: 100, Clear: 100, ClearJitter: 10, RefreshAhead: 10, MaxEntries: 1000, MaxBytes: 1 << 20, Eviction: "lfu"},
+	}
+
+	file, cleanup := setup(t, cfg)
+	defer cleanup()
+
+	res, err := LoadPath(file.Name())
+	require.NoError(t, err)
+	require.Equal(t, *cfg, *res)
+}
+
+func TestLoadPath_BadCache(t *testing.T) {
+	cases := []Cache{
+		{Driver: "memcached"},
+		{Driver: CacheDriverRedis},
+		{Driver: CacheDriverFile},
+		{MaxEntries: -1},
+		{Eviction: "fifo"},
+		{TTL: 100, RefreshAhead: 100},
+		{RefreshAhead: -1},
+		{Clear: -1},
+		{ClearJitter: -1},
+	}
+
+	for _, c := range cases {
+		file, cleanup := setup(t, &Config{Cache: c})
+
+		res, err := LoadPath(file.Name())
+		require.Error(t, err)
+		require.ErrorIs(t, err, errBadCache)
+		require.Empty(t, res)
+
+		cleanup()
+	}
+}
+
+func TestLoadPath_BadHTTP(t *testing.T) {
+	file, cleanup := setup(t, &Config{HTTP: HTTP{ShutdownTimeout: -1}})
+	defer cleanup()
+
+	res, err := LoadPath(file.Name())
+	require.ErrorIs(t, err, errBadHTTP)
+	require.Empty(t, res)
+}
+
+func TestLoadPath_Tracing(t *testing.T) {
+	cfg := &Config{
+		Env:  "local",
+		Port: 8080,
+		Tracing: Tracing{
+			Exporter:    TracingExporterOTLP,
+			Endpoint:    "http://localhost:4318/v1/traces",
+			Headers:     map[string]string{"Authorization": "Bearer token"},
+			Timeout:     5,
+			Service:     "mortgage-calculator",
+			SampleRatio: 0.25,
+		},
+	}
+
+	file, cleanup := setup(t, cfg)
+	defer cleanup()
+
+	res, err := LoadPath(file.Name())
+	require.NoError(t, err)
+	require.Equal(t, *cfg, *res)
+}
+
+func TestLoadPath_BadTracing(t *testing.T) {
+	cases := []Tracing{
+		{Exporter: "jaeger"},
+		{Exporter: TracingExporterFile},
+		{Exporter: TracingExporterOTLP},
+		{Exporter: TracingExporterOTLP, Endpoint: "localhost:4318"},
+		{SampleRatio: 1.5},
+		{SampleRatio: -0.5},
+		{Timeout: -1},
+	}
+
+	for _, c := range cases {
+		file, cleanup := setup(t, &Config{Tracing: c})
+
+		res, err := LoadPath(file.Name())
+		require.ErrorIs(t, err, errBadTracing)
+		require.Empty(t, res)
+
+		cleanup()
+	}
+}
+
 func TestMustLoadPath(t *testing.T) {
 	cfg := &Config{
 		Env:  "local",
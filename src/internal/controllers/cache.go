@@ -2,15 +2,27 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"log/slog"
 	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/domain/dto/requests"
+	"mortgage-calculator/src/internal/services"
 	"net/http"
+	"strconv"
 )
 
+var errBadEntryID = errors.New("invalid cache entry id")
+
 // CacheProvider interacts with cache.
 type CacheProvider interface {
-	List(ctx context.Context) ([]*dto.CacheEntry, error)
+	List(ctx context.Context, query dto.CacheQuery) (*dto.CacheList, error)
+	Stats(ctx context.Context, top int) (*dto.CacheStats, error)
+	Get(ctx context.Context, id int64) (*dto.CacheEntry, error)
+	Delete(ctx context.Context, id int64) error
+	Purge(ctx context.Context) (int, error)
+	ClearExpired(ctx context.Context) int
 }
 
 // CacheController deals with cache endpoints.
@@ -30,23 +42,137 @@ func NewCacheController(
 	}
 }
 
-// List lists all active cache entries.
+// List lists page of active cache entries matching query filters.
 func (con *CacheController) List(c *gin.Context) {
 	ctx := c.Request.Context()
-	entries, err := con.cache.List(ctx)
 
+	var query dto.CacheQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Errorf("%w: %s", errValidation, err.Error()).Error(),
+		})
+		return
+	}
+
+	entries, err := con.cache.List(ctx, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to retrieve cache entries",
 		})
 		return
 	}
-	if len(entries) == 0 {
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// Stats returns cache usage statistics.
+func (con *CacheController) Stats(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var in requests.CacheStatsRequest
+	if err := c.ShouldBindQuery(&in); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "empty cache",
+			"error": fmt.Errorf("%w: %s", errValidation, err.Error()).Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, entries)
+	stats, err := con.cache.Stats(ctx, in.Top)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to retrieve cache statistics",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// Get returns cache entry by id.
+func (con *CacheController) Get(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := entryID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	entry, err := con.cache.Get(ctx, id)
+	if err != nil {
+		writeCacheError(c, err, "failed to retrieve cache entry")
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// Delete deletes cache entry by id.
+func (con *CacheController) Delete(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := entryID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := con.cache.Delete(ctx, id); err != nil {
+		writeCacheError(c, err, "failed to delete cache entry")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Purge deletes all cache entries.
+func (con *CacheController) Purge(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	deleted, err := con.cache.Purge(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to purge cache",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deleted": deleted,
+	})
+}
+
+// ClearExpired deletes expired cache entries.
+func (con *CacheController) ClearExpired(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	c.JSON(http.StatusOK, gin.H{
+		"deleted": con.cache.ClearExpired(ctx),
+	})
+}
+
+func entryID(c *gin.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errBadEntryID
+	}
+
+	return id, nil
+}
+
+func writeCacheError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, services.ErrEntryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": services.ErrEntryNotFound.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": msg,
+	})
 }
@@ -9,14 +9,15 @@ import (
 	"io"
 	"log/slog"
 	"mortgage-calculator/src/internal/domain/dto"
-	reposmock "mortgage-calculator/src/internal/mocks/repos"
+	servicesmock "mortgage-calculator/src/internal/mocks/services"
+	"mortgage-calculator/src/internal/services"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 )
 
-func setup2() (*CacheController, *reposmock.MockCacheGetSaver) {
-	repo := new(reposmock.MockCacheGetSaver)
+func setup2() (*CacheController, *servicesmock.MockCacheProvider) {
+	repo := new(servicesmock.MockCacheProvider)
 	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
 	con := NewCacheController(log, repo)
 
@@ -24,7 +25,7 @@ func setup2() (*CacheController, *reposmock.MockCacheGetSaver) {
 }
 
 func TestNewCacheController(t *testing.T) {
-	repo := new(reposmock.MockCacheGetSaver)
+	repo := new(servicesmock.MockCacheProvider)
 	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
 	con := NewCacheController(log, repo)
 
@@ -41,16 +42,61 @@ func TestCacheController_List_EmptyCache(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/cache", nil)
 	c.Request = req
 
-	r.On("List", mock.Anything).Return([]*dto.CacheEntry{}, nil)
+	r.On("List", mock.Anything, dto.CacheQuery{}).Return(&dto.CacheList{Limit: 100, Entries: []*dto.CacheEntry{}}, nil)
 
 	con.List(c)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	require.Contains(
-		t,
-		w.Body.String(),
-		"empty cache",
-	)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"total":0,"limit":100,"offset":0,"entries":[]}`, w.Body.String())
+}
+
+func TestCacheController_List_Query(t *testing.T) {
+	con, r := setup2()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	url := "/cache?limit=10&offset=20&sort=loan_sum&order=desc&program=salary&min_months=120&max_months=240" +
+		"&min_object_cost=100000000&max_object_cost=900000000"
+	req, _ := http.NewRequest("GET", url, nil)
+	c.Request = req
+
+	query := dto.CacheQuery{
+		Limit:         10,
+		Offset:        20,
+		Sort:          dto.CacheSortLoanSum,
+		Order:         dto.OrderDesc,
+		Program:       "salary",
+		MinMonths:     120,
+		MaxMonths:     240,
+		MinObjectCost: 100000000,
+		MaxObjectCost: 900000000,
+	}
+	r.On("List", mock.Anything, query).Return(&dto.CacheList{Total: 21, Limit: 10, Offset: 20, Entries: []*dto.CacheEntry{{ID: 5}}}, nil)
+
+	con.List(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"total":21`)
+}
+
+func TestCacheController_List_BadQuery(t *testing.T) {
+	con, _ := setup2()
+
+	gin.SetMode(gin.TestMode)
+
+	for _, query := range []string{"limit=5000", "offset=-1", "sort=name", "order=up", "min_months=240&max_months=120"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		req, _ := http.NewRequest("GET", "/cache?"+query, nil)
+		c.Request = req
+
+		con.List(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
 }
 
 func TestCacheController_List_InternalError(t *testing.T) {
@@ -63,7 +109,7 @@ func TestCacheController_List_InternalError(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/cache", nil)
 	c.Request = req
 
-	r.On("List", mock.Anything).Return([]*dto.CacheEntry{}, errors.New("internal server error"))
+	r.On("List", mock.Anything, dto.CacheQuery{}).Return((*dto.CacheList)(nil), errors.New("internal server error"))
 
 	con.List(c)
 
@@ -74,3 +120,183 @@ func TestCacheController_List_InternalError(t *testing.T) {
 		"failed to retrieve cache entries",
 	)
 }
+
+func TestCacheController_Stats(t *testing.T) {
+	con, s := setup2()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequest("GET", "/cache/stats?top=5", nil)
+	c.Request = req
+
+	stats := &dto.CacheStats{
+		Hits:     3,
+		Misses:   1,
+		HitRatio: 0.75,
+		Entries:  1,
+		TopRequests: []*dto.RequestCount{
+			{Params: &dto.CalcParams{ObjectCost: 500000000, InitialPayment: 100000000, Months: 240}, Program: &dto.CalcProgram{ID: "salary"}, Count: 4},
+		},
+		Programs: map[string]int64{"salary": 4},
+		Terms:    map[int]int64{240: 4},
+	}
+	s.On("Stats", mock.Anything, 5).Return(stats, nil)
+
+	con.Stats(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(
+		t,
+		`{"hits":3,"misses":1,"sets":0,"coalesced":0,"evictions":0,"expirations":0,"hit_ratio":0.75,"entries":1,`+
+			`"top_requests":[{"params":{"object_cost":500000000,"initial_payment":100000000,"months":240},"program":"salary","count":4}],`+
+			`"programs":{"salary":4},"terms":{"240":4}}`,
+		w.Body.String(),
+	)
+}
+
+func TestCacheController_Stats_BadRequest(t *testing.T) {
+	con, _ := setup2()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequest("GET", "/cache/stats?top=1000", nil)
+	c.Request = req
+
+	con.Stats(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCacheController_Stats_InternalError(t *testing.T) {
+	con, s := setup2()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequest("GET", "/cache/stats", nil)
+	c.Request = req
+
+	s.On("Stats", mock.Anything, 0).Return((*dto.CacheStats)(nil), errors.New("internal server error"))
+
+	con.Stats(c)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Contains(t, w.Body.String(), "failed to retrieve cache statistics")
+}
+
+func TestCacheController_Get(t *testing.T) {
+	con, s := setup2()
+
+	s.On("Get", mock.Anything, int64(1)).Return(&dto.CacheEntry{ID: 1}, nil)
+	s.On("Get", mock.Anything, int64(2)).Return((*dto.CacheEntry)(nil), services.ErrEntryNotFound)
+	s.On("Get", mock.Anything, int64(3)).Return((*dto.CacheEntry)(nil), errors.New("internal server error"))
+
+	cases := []struct {
+		id   string
+		code int
+		body string
+	}{
+		{id: "1", code: http.StatusOK, body: `"id":1`},
+		{id: "2", code: http.StatusNotFound, body: "cache entry not found"},
+		{id: "3", code: http.StatusInternalServerError, body: "failed to retrieve cache entry"},
+		{id: "abc", code: http.StatusBadRequest, body: "invalid cache entry id"},
+		{id: "0", code: http.StatusBadRequest, body: "invalid cache entry id"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request, _ = http.NewRequest("GET", "/cache/"+tc.id, nil)
+		c.Params = gin.Params{{Key: "id", Value: tc.id}}
+
+		con.Get(c)
+
+		assert.Equal(t, tc.code, w.Code)
+		require.Contains(t, w.Body.String(), tc.body)
+	}
+}
+
+func TestCacheController_Delete(t *testing.T) {
+	con, s := setup2()
+
+	s.On("Delete", mock.Anything, int64(1)).Return(nil)
+	s.On("Delete", mock.Anything, int64(2)).Return(services.ErrEntryNotFound)
+
+	cases := []struct {
+		id   string
+		code int
+	}{
+		{id: "1", code: http.StatusNoContent},
+		{id: "2", code: http.StatusNotFound},
+		{id: "-1", code: http.StatusBadRequest},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request, _ = http.NewRequest("DELETE", "/cache/"+tc.id, nil)
+		c.Params = gin.Params{{Key: "id", Value: tc.id}}
+
+		con.Delete(c)
+		c.Writer.WriteHeaderNow()
+
+		assert.Equal(t, tc.code, w.Code)
+	}
+}
+
+func TestCacheController_Purge(t *testing.T) {
+	con, s := setup2()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("DELETE", "/cache", nil)
+
+	s.On("Purge", mock.Anything).Return(5, nil)
+
+	con.Purge(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"deleted":5}`, w.Body.String())
+}
+
+func TestCacheController_Purge_InternalError(t *testing.T) {
+	con, s := setup2()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("DELETE", "/cache", nil)
+
+	s.On("Purge", mock.Anything).Return(0, errors.New("internal server error"))
+
+	con.Purge(c)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Contains(t, w.Body.String(), "failed to purge cache")
+}
+
+func TestCacheController_ClearExpired(t *testing.T) {
+	con, s := setup2()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/cache/clear-expired", nil)
+
+	s.On("ClearExpired", mock.Anything).Return(2)
+
+	con.ClearExpired(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"deleted":2}`, w.Body.String())
+}
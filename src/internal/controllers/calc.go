@@ -10,6 +10,8 @@ import (
 	"log/slog"
 	"mortgage-calculator/src/internal/domain/dto"
 	"mortgage-calculator/src/internal/domain/dto/requests"
+	"mortgage-calculator/src/internal/lib/clock"
+	"mortgage-calculator/src/internal/lib/tracing"
 	"mortgage-calculator/src/internal/services"
 	"net/http"
 )
@@ -17,12 +19,23 @@ import (
 // Calculator calculates result based on given parameters.
 type Calculator interface {
 	Calculate(ctx context.Context, params dto.CalcParams, program dto.CalcProgram) (*dto.CalcAggregates, error)
+	Schedule(ctx context.Context, params dto.CalcParams, program dto.CalcProgram) (*dto.CalcSchedule, error)
+	Programs() []dto.Program
+	Affordability(
+		ctx context.Context,
+		borrower dto.Borrower,
+		aggregates *dto.CalcAggregates,
+		program dto.CalcProgram,
+	) (*dto.Affordability, error)
 }
 
 // CacheGetSaver interacts with cache.
 type CacheGetSaver interface {
-	Get(ctx context.Context, in *requests.CalculateRequest) (*dto.CalcAggregates, error)
-	Set(ctx context.Context, in *requests.CalculateRequest, aggregates *dto.CalcAggregates) error
+	GetOrCompute(
+		ctx context.Context,
+		in *requests.CalculateRequest,
+		compute func(ctx context.Context) (*dto.CalcAggregates, error),
+	) (*dto.CalcAggregates, error)
 }
 
 // CalcController deals with calculation endpoints.
@@ -30,6 +43,7 @@ type CalcController struct {
 	log        *slog.Logger
 	calculator Calculator
 	cache      CacheGetSaver
+	clock      clock.Clock
 }
 
 // NewCalcController is a constructor for CalcController.
@@ -37,79 +51,188 @@ func NewCalcController(
 	log *slog.Logger,
 	calculator Calculator,
 	cache CacheGetSaver,
+	clk clock.Clock,
 ) *CalcController {
 	return &CalcController{
 		log:        log,
 		calculator: calculator,
 		cache:      cache,
+		clock:      clk,
 	}
 }
 
 type calculateResponse struct {
-	Aggregates dto.CalcAggregates `json:"aggregates"`
-	Params     dto.CalcParams     `json:"params"`
-	Program    dto.CalcProgram    `json:"program"`
+	Aggregates    dto.CalcAggregates `json:"aggregates"`
+	Params        dto.CalcParams     `json:"params"`
+	Program       dto.CalcProgram    `json:"program"`
+	Affordability *dto.Affordability `json:"affordability,omitempty"`
 }
 
 // Calculate validates request params, calculates params and composes result message.
 func (con *CalcController) Calculate(c *gin.Context) {
-	ctx := c.Request.Context()
+	ctx, span := tracing.Start(c.Request.Context(), "CalcController.Calculate")
+	defer span.End()
 
 	// validate request
 	in, err := validateRequest(c)
 	if err != nil {
+		span.RecordError(err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	params := dto.CalcParams{
-		ObjectCost:     in.ObjectCost,
-		InitialPayment: in.InitialPayment,
-		Months:         in.Months,
-	}
+	span.SetAttributes(tracing.String("program", in.Program.Key()))
+
+	params := con.calcParams(in)
+
+	// borrower doesn't affect aggregates, so it is neither a part of cache key nor stored in cache
+	borrower := in.Borrower
+	in.Borrower = nil
 
-	// retrieve result from cache
-	res, err := con.cache.Get(ctx, in)
+	res, err := con.aggregates(ctx, in, params)
 	if err != nil {
-		// calculate result
-		res, err = con.calculator.Calculate(ctx, params, in.Program)
+		span.RecordError(err)
+		writeCalcError(c, err)
+		return
+	}
 
+	// check affordability if borrower is given
+	var aff *dto.Affordability
+	if borrower != nil {
+		aff, err = con.calculator.Affordability(ctx, *borrower, res, in.Program)
 		if err != nil {
-			if errors.Is(err, services.ErrInsufficientInitialPayment) {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error": services.ErrInsufficientInitialPayment.Error(),
-				})
-				return
-			}
-
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "failed to calculate params",
-			})
+			span.RecordError(err)
+			writeCalcError(c, err)
 			return
 		}
-
-		// save calculated result
-		if err := con.cache.Set(ctx, in, res); err != nil {
-			con.log.Warn("failed to cache result", slog.Any("error", err))
-		}
 	}
 
 	// compose response
 	out := calculateResponse{
+		Params:        params,
+		Program:       in.Program,
+		Aggregates:    *res,
+		Affordability: aff,
+	}
+
+	c.JSON(200, out)
+}
+
+// calcParams pins start date of request and returns its params passed to calculator.
+func (con *CalcController) calcParams(in *requests.CalculateRequest) dto.CalcParams {
+	con.pinStartDate(&in.CalcParams)
+
+	return in.CalcParams
+}
+
+// pinStartDate fills empty start date with today's date,
+// so calculation doesn't depend on request time and the date becomes a part of cache key.
+func (con *CalcController) pinStartDate(params *dto.CalcParams) {
+	if params.StartDate == "" {
+		params.StartDate = con.clock.Now().Format(dto.DateFormat)
+	}
+}
+
+// aggregates retrieves result from cache or calculates and caches it.
+func (con *CalcController) aggregates(
+	ctx context.Context,
+	in *requests.CalculateRequest,
+	params dto.CalcParams,
+) (*dto.CalcAggregates, error) {
+	calculate := func(ctx context.Context) (*dto.CalcAggregates, error) {
+		return con.calculator.Calculate(ctx, params, in.Program) //nolint:wrapcheck // mapped to response by writeCalcError
+	}
+
+	return con.cache.GetOrCompute(ctx, in, calculate) //nolint:wrapcheck // mapped to response by writeCalcError
+}
+
+type scheduleResponse struct {
+	Aggregates dto.CalcAggregates    `json:"aggregates"`
+	Params     dto.CalcParams        `json:"params"`
+	Program    dto.CalcProgram       `json:"program"`
+	Payments   []dto.SchedulePayment `json:"payments"`
+	Totals     dto.ScheduleTotals    `json:"totals"`
+}
+
+// Schedule validates request params and composes full amortization schedule.
+func (con *CalcController) Schedule(c *gin.Context) {
+	ctx, span := tracing.Start(c.Request.Context(), "CalcController.Schedule")
+	defer span.End()
+
+	// validate request
+	in, err := validateRequest(c)
+	if err != nil {
+		span.RecordError(err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	span.SetAttributes(tracing.String("program", in.Program.Key()))
+
+	params := con.calcParams(in)
+
+	res, err := con.calculator.Schedule(ctx, params, in.Program)
+	if err != nil {
+		span.RecordError(err)
+		writeCalcError(c, err)
+		return
+	}
+
+	// compose response
+	out := scheduleResponse{
+		Aggregates: res.Aggregates,
 		Params:     params,
 		Program:    in.Program,
-		Aggregates: *res,
+		Payments:   res.Payments,
+		Totals:     res.Totals,
 	}
 
-	c.JSON(200, out)
+	c.JSON(http.StatusOK, out)
+}
+
+// badCalcErrors are calculator errors caused by request params.
+var badCalcErrors = []error{
+	services.ErrUnknownProgram,
+	services.ErrPaymentTooLow,
+	services.ErrLoanSumOutOfRange,
+	services.ErrBadStartDate,
+	services.ErrAmountOutOfRange,
+}
+
+// writeCalcError maps calculator errors to http responses.
+func writeCalcError(c *gin.Context, err error) {
+	var eligibilityErr *services.EligibilityError
+	if errors.As(err, &eligibilityErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      eligibilityErr.Error(),
+			"violations": eligibilityErr.Violations,
+		})
+		return
+	}
+
+	for _, badErr := range badCalcErrors {
+		if errors.Is(err, badErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": badErr.Error(),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": "failed to calculate params",
+	})
 }
 
 var errNoPayload = errors.New("no json payload")
 var errValidation = errors.New("validation error")
 var errNoProgram = errors.New("choose program")
 var errTooManyPrograms = errors.New("choose only 1 program")
+var errEarlyRepaymentMonth = errors.New("early repayment month is out of term")
 
 func validateRequest(c *gin.Context) (*requests.CalculateRequest, error) {
 	// validate request
@@ -123,16 +246,7 @@ func validateRequest(c *gin.Context) (*requests.CalculateRequest, error) {
 		return nil, fmt.Errorf("%w: %s", errValidation, err.Error())
 	}
 
-	var programCount int
-	if in.Program.Base {
-		programCount++
-	}
-	if in.Program.Military {
-		programCount++
-	}
-	if in.Program.Salary {
-		programCount++
-	}
+	programCount := in.Program.Count()
 
 	// program must be specified
 	if programCount == 0 {
@@ -144,5 +258,20 @@ func validateRequest(c *gin.Context) (*requests.CalculateRequest, error) {
 		return nil, errTooManyPrograms
 	}
 
+	if err := validateEarlyRepayments(in.CalcParams); err != nil {
+		return nil, err
+	}
+
 	return &in, nil
 }
+
+// validateEarlyRepayments checks that early repayments are made within the term.
+func validateEarlyRepayments(params dto.CalcParams) error {
+	for _, r := range params.EarlyRepayments {
+		if r.Month > params.Months {
+			return errEarlyRepaymentMonth
+		}
+	}
+
+	return nil
+}
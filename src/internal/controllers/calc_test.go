@@ -3,6 +3,7 @@ package controllers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -12,18 +13,25 @@ import (
 	cachepkg "mortgage-calculator/src/internal/cache"
 	"mortgage-calculator/src/internal/domain/dto"
 	"mortgage-calculator/src/internal/domain/dto/requests"
+	"mortgage-calculator/src/internal/lib/clock"
+	"mortgage-calculator/src/internal/lib/money"
 	reposmock "mortgage-calculator/src/internal/mocks/repos"
 	servicesmock "mortgage-calculator/src/internal/mocks/services"
+	"mortgage-calculator/src/internal/services"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
+// testClock pins the date of calculations.
+var testClock = clock.Fixed(time.Date(2024, 6, 18, 15, 4, 5, 0, time.UTC))
+
 func setup() (*CalcController, *servicesmock.MockCalculator, *reposmock.MockCacheGetSaver) {
 	service := new(servicesmock.MockCalculator)
 	repo := new(reposmock.MockCacheGetSaver)
 	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
-	con := NewCalcController(log, service, repo)
+	con := NewCalcController(log, service, repo, testClock)
 
 	return con, service, repo
 }
@@ -32,7 +40,7 @@ func TestNewCalcController(t *testing.T) {
 	service := new(servicesmock.MockCalculator)
 	repo := new(reposmock.MockCacheGetSaver)
 	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
-	con := NewCalcController(log, service, repo)
+	con := NewCalcController(log, service, repo, testClock)
 
 	require.NotEmpty(t, con)
 }
@@ -62,9 +70,15 @@ func TestCalcController_Calculate(t *testing.T) {
 	r.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	s.On("Calculate", mock.Anything, mock.Anything, mock.Anything).Return(&dto.CalcAggregates{
 		LastPaymentDate: "1",
-		Rate:            2,
+		PaymentType:     "annuity",
+		Currency:        money.Currency{Code: "RUB", MinorUnits: 2},
+		Rate:            8.5,
+		RateBps:         850,
 		LoanSum:         3,
 		MonthlyPayment:  4,
+		FirstPayment:    4,
+		LastPayment:     4,
+		MaxPayment:      4,
 		Overpayment:     5,
 	}, nil)
 
@@ -74,10 +88,239 @@ func TestCalcController_Calculate(t *testing.T) {
 	require.Contains(
 		t,
 		w.Body.String(),
-		"{\"aggregates\":{\"last_payment_date\":\"1\",\"rate\":2,\"loan_sum\":3,\"monthly_payment\":4,\"overpayment\":5},\"params\":{\"object_cost\":100,\"initial_payment\":20,\"months\":12},\"program\":{\"salary\":true}}",
+		"{\"aggregates\":{\"last_payment_date\":\"1\",\"payment_type\":\"annuity\",\"currency\":{\"code\":\"RUB\",\"minor_units\":2},\"rate\":8.5,\"rate_bps\":850,\"loan_sum\":3,\"monthly_payment\":4,\"first_payment\":4,\"last_payment\":4,\"max_payment\":4,\"overpayment\":5},\"params\":{\"object_cost\":100,\"initial_payment\":20,\"months\":12,\"start_date\":\"2024-06-18\"},\"program\":{\"salary\":true}}",
+	)
+}
+
+func TestCalcController_Calculate_Affordability(t *testing.T) {
+	con, s, r := setup()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequest(
+		"POST",
+		"/calculate",
+		bytes.NewBufferString(`{"object_cost":100,"initial_payment":20,"months":12,"program":"salary","borrower":{"monthly_income":10,"obligations":2}}`),
+	)
+	c.Request = req
+
+	aggregates := &dto.CalcAggregates{MonthlyPayment: 7, MaxPayment: 7}
+
+	// borrower must not be a part of cache key
+	r.On("Get", mock.Anything, mock.MatchedBy(func(in *requests.CalculateRequest) bool {
+		return in.Borrower == nil
+	})).Return(aggregates, nil)
+	s.On("Affordability", mock.Anything, dto.Borrower{MonthlyIncome: 10, Obligations: 2}, aggregates, dto.CalcProgram{ID: "salary"}).
+		Return(&dto.Affordability{MonthlyIncome: 10, Obligations: 2, Payment: 7, DebtToIncome: 0.9, MaxDebtToIncome: 0.5, MaxPayment: 3}, nil)
+
+	con.Calculate(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var out calculateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+	require.NotNil(t, out.Affordability)
+	require.False(t, out.Affordability.Affordable)
+	require.Equal(t, money.Amount(3), out.Affordability.MaxPayment)
+
+	s.AssertNotCalled(t, "Calculate")
+}
+
+func TestCalcController_Calculate_StartDate(t *testing.T) {
+	cases := []struct {
+		body      string
+		startDate string
+	}{
+		// empty date is pinned to today's date
+		{`{"object_cost":100,"initial_payment":20,"months":12,"program":"base"}`, "2024-06-18"},
+		{`{"object_cost":100,"initial_payment":20,"months":12,"program":"base","start_date":"2020-01-31"}`, "2020-01-31"},
+	}
+
+	for _, tt := range cases {
+		con, s, r := setup()
+
+		gin.SetMode(gin.TestMode)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		req, _ := http.NewRequest("POST", "/calculate", bytes.NewBufferString(tt.body))
+		c.Request = req
+
+		// effective date must be a part of cache key
+		r.On("Get", mock.Anything, mock.MatchedBy(func(in *requests.CalculateRequest) bool {
+			return in.StartDate == tt.startDate
+		})).Return(&dto.CalcAggregates{}, cachepkg.ErrKeyNotExists)
+		r.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		s.On("Calculate", mock.Anything, mock.MatchedBy(func(params dto.CalcParams) bool {
+			return params.StartDate == tt.startDate
+		}), mock.Anything).Return(&dto.CalcAggregates{}, nil)
+
+		con.Calculate(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		r.AssertExpectations(t)
+		s.AssertExpectations(t)
+	}
+}
+
+func TestCalcController_Calculate_InsufficientInitialPayment(t *testing.T) {
+	con, s, r := setup()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	body, err := json.Marshal(requests.CalculateRequest{
+		CalcParams: dto.CalcParams{
+			ObjectCost:     100,
+			InitialPayment: 1,
+			Months:         12,
+		},
+		Program: dto.CalcProgram{
+			Salary: true,
+		},
+	})
+	require.NoError(t, err)
+	req, _ := http.NewRequest("POST", "/calculate", bytes.NewBuffer(body))
+	c.Request = req
+
+	r.On("Get", mock.Anything, mock.Anything).Return(&dto.CalcAggregates{}, cachepkg.ErrKeyNotExists)
+	s.On("Calculate", mock.Anything, mock.Anything, mock.Anything).
+		Return((*dto.CalcAggregates)(nil), &services.EligibilityError{
+			Violations: []dto.Violation{
+				{
+					Code:    services.CodeMinInitialPaymentRatio,
+					Field:   "initial_payment",
+					Message: services.ErrInsufficientInitialPayment.Error(),
+					Limit:   0.2,
+					Actual:  0.01,
+				},
+			},
+		})
+
+	con.Calculate(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(
+		t,
+		`{
+			"error": "the initial payment should be more",
+			"violations": [
+				{
+					"code": "min_initial_payment_ratio",
+					"field": "initial_payment",
+					"message": "the initial payment should be more",
+					"limit": 0.2,
+					"actual": 0.01
+				}
+			]
+		}`,
+		w.Body.String(),
+	)
+	r.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCalcController_Calculate_UnknownProgram(t *testing.T) {
+	con, s, r := setup()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequest(
+		"POST",
+		"/calculate",
+		bytes.NewBufferString(`{"object_cost":100,"initial_payment":20,"months":12,"program":"family"}`),
+	)
+	c.Request = req
+
+	r.On("Get", mock.Anything, mock.Anything).Return(&dto.CalcAggregates{}, cachepkg.ErrKeyNotExists)
+	s.On("Calculate", mock.Anything, mock.Anything, dto.CalcProgram{ID: "family"}).
+		Return((*dto.CalcAggregates)(nil), services.ErrUnknownProgram)
+
+	con.Calculate(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), services.ErrUnknownProgram.Error())
+}
+
+func TestCalcController_Schedule(t *testing.T) {
+	con, s, _ := setup()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	body, err := json.Marshal(requests.CalculateRequest{
+		CalcParams: dto.CalcParams{
+			ObjectCost:     100,
+			InitialPayment: 20,
+			Months:         1,
+		},
+		Program: dto.CalcProgram{
+			Base: true,
+		},
+	})
+	require.NoError(t, err)
+	req, _ := http.NewRequest("POST", "/schedule", bytes.NewBuffer(body))
+	c.Request = req
+
+	s.On("Schedule", mock.Anything, mock.Anything, mock.Anything).Return(&dto.CalcSchedule{
+		Aggregates: dto.CalcAggregates{
+			LastPaymentDate: "1",
+			Rate:            10,
+			LoanSum:         80,
+			MonthlyPayment:  81,
+			Overpayment:     1,
+		},
+		Payments: []dto.SchedulePayment{
+			{Month: 1, PaymentDate: "1", Payment: 81, Principal: 80, Interest: 1, Balance: 0},
+		},
+		Totals: dto.ScheduleTotals{Payment: 81, Principal: 80, Interest: 1},
+	}, nil)
+
+	con.Schedule(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Contains(
+		t,
+		w.Body.String(),
+		"\"payments\":[{\"month\":1,\"payment_date\":\"1\",\"payment\":81,\"principal\":80,\"interest\":1,\"balance\":0}],\"totals\":{\"payment\":81,\"principal\":80,\"interest\":1}",
 	)
 }
 
+func TestCalcController_Schedule_InternalError(t *testing.T) {
+	con, s, _ := setup()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	body, err := json.Marshal(requests.CalculateRequest{
+		CalcParams: dto.CalcParams{
+			ObjectCost:     100,
+			InitialPayment: 20,
+			Months:         12,
+		},
+		Program: dto.CalcProgram{
+			Base: true,
+		},
+	})
+	require.NoError(t, err)
+	req, _ := http.NewRequest("POST", "/schedule", bytes.NewBuffer(body))
+	c.Request = req
+
+	s.On("Schedule", mock.Anything, mock.Anything, mock.Anything).
+		Return((*dto.CalcSchedule)(nil), errors.New("internal error"))
+
+	con.Schedule(c)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Contains(t, w.Body.String(), "failed to calculate params")
+}
+
 func TestValidateRequest_PassCases(t *testing.T) {
 	cases := []struct {
 		in requests.CalculateRequest
@@ -106,6 +349,47 @@ func TestValidateRequest_PassCases(t *testing.T) {
 				},
 			},
 		},
+		{
+			requests.CalculateRequest{
+				CalcParams: dto.CalcParams{
+					ObjectCost:     5000000,
+					InitialPayment: 1000000,
+					Months:         120,
+					PaymentType:    dto.PaymentTypeDifferentiated,
+				},
+				Program: dto.CalcProgram{
+					Military: true,
+				},
+			},
+		},
+		{
+			requests.CalculateRequest{
+				CalcParams: dto.CalcParams{
+					ObjectCost:     5000000,
+					InitialPayment: 1000000,
+					Months:         120,
+				},
+				Program: dto.CalcProgram{
+					ID: "family",
+				},
+			},
+		},
+		{
+			requests.CalculateRequest{
+				CalcParams: dto.CalcParams{
+					ObjectCost:     5000000,
+					InitialPayment: 1000000,
+					Months:         120,
+					EarlyRepayments: []dto.EarlyRepayment{
+						{Month: 12, Amount: 100000, Strategy: dto.StrategyReduceTerm},
+						{Month: 120, Amount: 100000, Strategy: dto.StrategyReducePayment},
+					},
+				},
+				Program: dto.CalcProgram{
+					Base: true,
+				},
+			},
+		},
 	}
 
 	gin.SetMode(gin.TestMode)
@@ -161,6 +445,110 @@ func TestValidateRequest_FailCases(t *testing.T) {
 			},
 			errValidation,
 		},
+		{
+			requests.CalculateRequest{
+				CalcParams: dto.CalcParams{
+					ObjectCost:     100,
+					InitialPayment: 20,
+					Months:         dto.MaxMonths + 1,
+				},
+				Program: dto.CalcProgram{Base: true},
+			},
+			errValidation,
+		},
+		{
+			requests.CalculateRequest{
+				CalcParams: dto.CalcParams{
+					ObjectCost:     dto.MaxAmount + 1,
+					InitialPayment: 20,
+					Months:         12,
+				},
+				Program: dto.CalcProgram{Base: true},
+			},
+			errValidation,
+		},
+		{
+			requests.CalculateRequest{
+				CalcParams: dto.CalcParams{
+					ObjectCost:     -100,
+					InitialPayment: -200,
+					Months:         12,
+				},
+				Program: dto.CalcProgram{Base: true},
+			},
+			errValidation,
+		},
+		{
+			requests.CalculateRequest{
+				CalcParams: dto.CalcParams{
+					ObjectCost:     100,
+					InitialPayment: 20,
+					Months:         -12,
+				},
+				Program: dto.CalcProgram{Base: true},
+			},
+			errValidation,
+		},
+		{
+			requests.CalculateRequest{
+				CalcParams: dto.CalcParams{
+					ObjectCost:     100,
+					InitialPayment: 20,
+					Months:         12,
+					EarlyRepayments: []dto.EarlyRepayment{
+						{Month: 13, Amount: 10, Strategy: dto.StrategyReduceTerm},
+					},
+				},
+				Program: dto.CalcProgram{
+					Base: true,
+				},
+			},
+			errEarlyRepaymentMonth,
+		},
+		{
+			requests.CalculateRequest{
+				CalcParams: dto.CalcParams{
+					ObjectCost:     100,
+					InitialPayment: 20,
+					Months:         12,
+					StartDate:      "18.06.2024",
+				},
+				Program: dto.CalcProgram{
+					Base: true,
+				},
+			},
+			errValidation,
+		},
+		{
+			requests.CalculateRequest{
+				CalcParams: dto.CalcParams{
+					ObjectCost:     100,
+					InitialPayment: 20,
+					Months:         12,
+					EarlyRepayments: []dto.EarlyRepayment{
+						{Month: 1, Amount: 10, Strategy: "skip"},
+					},
+				},
+				Program: dto.CalcProgram{
+					Base: true,
+				},
+			},
+			errValidation,
+		},
+		{
+			requests.CalculateRequest{
+				CalcParams: dto.CalcParams{
+					ObjectCost:     100,
+					InitialPayment: 20,
+					Months:         120,
+					PaymentType:    "balloon",
+				},
+				Program: dto.CalcProgram{
+					Base: true,
+				},
+			},
+			errValidation,
+		},
 	}
 
 	gin.SetMode(gin.TestMode)
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/domain/dto/requests"
+	"mortgage-calculator/src/internal/lib/money"
+	"mortgage-calculator/src/internal/services"
+	"net/http"
+	"sort"
+)
+
+type compareResult struct {
+	Rank               int                `json:"rank"`
+	Program            dto.CalcProgram    `json:"program"`
+	Aggregates         dto.CalcAggregates `json:"aggregates"`
+	MonthlyPaymentDiff money.Amount       `json:"monthly_payment_diff"` // MonthlyPaymentDiff is a difference with the best result.
+	OverpaymentDiff    money.Amount       `json:"overpayment_diff"`     // OverpaymentDiff is a difference with the best result.
+}
+
+type ineligibleProgram struct {
+	Program    dto.CalcProgram `json:"program"`
+	Violations []dto.Violation `json:"violations"`
+}
+
+type compareResponse struct {
+	Params     dto.CalcParams      `json:"params"`
+	Results    []compareResult     `json:"results"`
+	Ineligible []ineligibleProgram `json:"ineligible"`
+}
+
+// Compare calculates params for every requested program and ranks results by overpayment.
+// Programs which params are not eligible for are listed separately.
+func (con *CalcController) Compare(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// validate request
+	in, err := validateCompareRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	con.pinStartDate(&in.CalcParams)
+
+	programs := in.Programs
+	if len(programs) == 0 {
+		for _, p := range con.calculator.Programs() {
+			programs = append(programs, dto.CalcProgram{ID: p.ID})
+		}
+	}
+
+	out := compareResponse{
+		Params:     in.CalcParams,
+		Results:    make([]compareResult, 0, len(programs)),
+		Ineligible: make([]ineligibleProgram, 0),
+	}
+
+	for _, program := range programs {
+		variant := &requests.CalculateRequest{
+			CalcParams: in.CalcParams,
+			Program:    program,
+		}
+
+		res, err := con.aggregates(ctx, variant, in.CalcParams)
+
+		var eligibilityErr *services.EligibilityError
+		switch {
+		case errors.As(err, &eligibilityErr):
+			out.Ineligible = append(out.Ineligible, ineligibleProgram{
+				Program:    program,
+				Violations: eligibilityErr.Violations,
+			})
+		case err != nil:
+			writeCalcError(c, err)
+			return
+		default:
+			out.Results = append(out.Results, compareResult{
+				Program:    program,
+				Aggregates: *res,
+			})
+		}
+	}
+
+	rank(out.Results)
+
+	c.JSON(http.StatusOK, out)
+}
+
+// rank sorts results by overpayment and monthly payment and computes differences with the best result.
+func rank(results []compareResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].Aggregates, results[j].Aggregates
+		if a.Overpayment != b.Overpayment {
+			return a.Overpayment < b.Overpayment
+		}
+
+		return a.MonthlyPayment < b.MonthlyPayment
+	})
+
+	for i := range results {
+		results[i].Rank = i + 1
+		results[i].MonthlyPaymentDiff = results[i].Aggregates.MonthlyPayment - results[0].Aggregates.MonthlyPayment
+		results[i].OverpaymentDiff = results[i].Aggregates.Overpayment - results[0].Aggregates.Overpayment
+	}
+}
+
+var errDuplicateProgram = errors.New("programs must be unique")
+
+func validateCompareRequest(c *gin.Context) (*requests.CompareRequest, error) {
+	var in requests.CompareRequest
+	err := c.ShouldBindJSON(&in)
+
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errNoPayload
+		}
+		return nil, fmt.Errorf("%w: %s", errValidation, err.Error())
+	}
+
+	seen := make(map[string]struct{}, len(in.Programs))
+	for _, p := range in.Programs {
+		// every program must be specified exactly once
+		switch {
+		case p.Count() == 0:
+			return nil, errNoProgram
+		case p.Count() > 1:
+			return nil, errTooManyPrograms
+		}
+
+		if _, ok := seen[p.Key()]; ok {
+			return nil, errDuplicateProgram
+		}
+		seen[p.Key()] = struct{}{}
+	}
+
+	if err := validateEarlyRepayments(in.CalcParams); err != nil {
+		return nil, err
+	}
+
+	return &in, nil
+}
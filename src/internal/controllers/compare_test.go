@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	cachepkg "mortgage-calculator/src/internal/cache"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/domain/dto/requests"
+	"mortgage-calculator/src/internal/lib/money"
+	"mortgage-calculator/src/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCalcController_Compare_AllPrograms(t *testing.T) {
+	con, s, r := setup()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequest(
+		"POST",
+		"/compare",
+		bytes.NewBufferString(`{"object_cost":1000,"initial_payment":200,"months":12}`),
+	)
+	c.Request = req
+
+	s.On("Programs").Return([]dto.Program{{ID: "base"}, {ID: "salary"}, {ID: "military"}})
+	r.On("Get", mock.Anything, mock.Anything).Return(&dto.CalcAggregates{}, cachepkg.ErrKeyNotExists)
+	r.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.On("Calculate", mock.Anything, mock.Anything, dto.CalcProgram{ID: "base"}).
+		Return(&dto.CalcAggregates{MonthlyPayment: 71, Overpayment: 52}, nil)
+	s.On("Calculate", mock.Anything, mock.Anything, dto.CalcProgram{ID: "salary"}).
+		Return(&dto.CalcAggregates{MonthlyPayment: 70, Overpayment: 40}, nil)
+	s.On("Calculate", mock.Anything, mock.Anything, dto.CalcProgram{ID: "military"}).
+		Return((*dto.CalcAggregates)(nil), &services.EligibilityError{
+			Violations: []dto.Violation{{Code: services.CodeMinMonths, Field: "months", Message: "m", Limit: 24, Actual: 12}},
+		})
+
+	con.Compare(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var out compareResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+
+	require.Len(t, out.Results, 2)
+	require.Equal(t, 1, out.Results[0].Rank)
+	require.Equal(t, dto.CalcProgram{ID: "salary"}, out.Results[0].Program)
+	require.Equal(t, money.Amount(0), out.Results[0].OverpaymentDiff)
+	require.Equal(t, 2, out.Results[1].Rank)
+	require.Equal(t, dto.CalcProgram{ID: "base"}, out.Results[1].Program)
+	require.Equal(t, money.Amount(1), out.Results[1].MonthlyPaymentDiff)
+	require.Equal(t, money.Amount(12), out.Results[1].OverpaymentDiff)
+
+	require.Len(t, out.Ineligible, 1)
+	require.Equal(t, dto.CalcProgram{ID: "military"}, out.Ineligible[0].Program)
+	require.Equal(t, services.CodeMinMonths, out.Ineligible[0].Violations[0].Code)
+
+	r.AssertNumberOfCalls(t, "Set", 2)
+}
+
+func TestCalcController_Compare_CachedVariant(t *testing.T) {
+	con, s, r := setup()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequest(
+		"POST",
+		"/compare",
+		bytes.NewBufferString(`{"object_cost":1000,"initial_payment":200,"months":12,"programs":["salary",{"base":true}]}`),
+	)
+	c.Request = req
+
+	r.On("Get", mock.Anything, mock.MatchedBy(func(in *requests.CalculateRequest) bool {
+		return in.Program.Base
+	})).Return(&dto.CalcAggregates{MonthlyPayment: 71, Overpayment: 52}, nil)
+	r.On("Get", mock.Anything, mock.Anything).Return(&dto.CalcAggregates{}, cachepkg.ErrKeyNotExists)
+	r.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.On("Calculate", mock.Anything, mock.Anything, dto.CalcProgram{ID: "salary"}).
+		Return(&dto.CalcAggregates{MonthlyPayment: 70, Overpayment: 40}, nil)
+
+	con.Compare(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var out compareResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+	require.Len(t, out.Results, 2)
+	require.Equal(t, dto.CalcProgram{Base: true}, out.Results[1].Program)
+	require.Empty(t, out.Ineligible)
+
+	s.AssertNotCalled(t, "Programs")
+	s.AssertNumberOfCalls(t, "Calculate", 1)
+	r.AssertNumberOfCalls(t, "Set", 1)
+}
+
+func TestCalcController_Compare_UnknownProgram(t *testing.T) {
+	con, s, r := setup()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequest(
+		"POST",
+		"/compare",
+		bytes.NewBufferString(`{"object_cost":1000,"initial_payment":200,"months":12,"programs":["family"]}`),
+	)
+	c.Request = req
+
+	r.On("Get", mock.Anything, mock.Anything).Return(&dto.CalcAggregates{}, cachepkg.ErrKeyNotExists)
+	s.On("Calculate", mock.Anything, mock.Anything, mock.Anything).
+		Return((*dto.CalcAggregates)(nil), services.ErrUnknownProgram)
+
+	con.Compare(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), services.ErrUnknownProgram.Error())
+}
+
+func TestValidateCompareRequest_FailCases(t *testing.T) {
+	cases := []struct {
+		body string
+		err  error
+	}{
+		{``, errNoPayload},
+		{`{"object_cost":1000,"months":12}`, errValidation},
+		{`{"object_cost":1000,"initial_payment":200,"months":12,"programs":[{}]}`, errNoProgram},
+		{`{"object_cost":1000,"initial_payment":200,"months":12,"programs":[{"base":true,"salary":true}]}`, errTooManyPrograms},
+		{`{"object_cost":1000,"initial_payment":200,"months":12,"programs":["base",{"base":true}]}`, errDuplicateProgram},
+		{
+			`{"object_cost":1000,"initial_payment":200,"months":12,"early_repayments":[{"month":13,"amount":1,"strategy":"reduce_term"}]}`,
+			errEarlyRepaymentMonth,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+
+	for _, tt := range cases {
+		c, _ := gin.CreateTestContext(w)
+		req, _ := http.NewRequest("POST", "/compare", bytes.NewBufferString(tt.body))
+		c.Request = req
+		data, err := validateCompareRequest(c)
+
+		require.Error(t, err)
+		require.Empty(t, data)
+		require.ErrorIs(t, err, tt.err)
+	}
+}
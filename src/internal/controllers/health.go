@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"mortgage-calculator/src/internal/domain/dto"
+	"net/http"
+)
+
+// HealthProvider reports status of application.
+type HealthProvider interface {
+	Live(ctx context.Context) *dto.Health
+	Ready(ctx context.Context) *dto.Health
+}
+
+// HealthController deals with health endpoints.
+type HealthController struct {
+	log    *slog.Logger
+	health HealthProvider
+}
+
+// NewHealthController is a constructor for HealthController.
+func NewHealthController(
+	log *slog.Logger,
+	health HealthProvider,
+) *HealthController {
+	return &HealthController{
+		log:    log,
+		health: health,
+	}
+}
+
+// Live reports that process is alive.
+func (con *HealthController) Live(c *gin.Context) {
+	writeHealth(c, con.health.Live(c.Request.Context()))
+}
+
+// Ready reports whether application and its components are able to serve requests.
+func (con *HealthController) Ready(c *gin.Context) {
+	writeHealth(c, con.health.Ready(c.Request.Context()))
+}
+
+// writeHealth responds with 503 when any check has failed.
+func writeHealth(c *gin.Context, health *dto.Health) {
+	if health.Status != dto.HealthStatusOK {
+		c.JSON(http.StatusServiceUnavailable, health)
+		return
+	}
+
+	c.JSON(http.StatusOK, health)
+}
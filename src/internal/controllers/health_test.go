@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"mortgage-calculator/src/internal/domain/dto"
+	servicesmock "mortgage-calculator/src/internal/mocks/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setupHealth() (*HealthController, *servicesmock.MockHealthProvider) {
+	health := new(servicesmock.MockHealthProvider)
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+
+	return NewHealthController(log, health), health
+}
+
+func TestHealthController_Live(t *testing.T) {
+	con, h := setupHealth()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/healthz", nil)
+
+	h.On("Live", mock.Anything).Return(&dto.Health{Status: dto.HealthStatusOK})
+
+	con.Live(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestHealthController_Ready(t *testing.T) {
+	cases := []struct {
+		health *dto.Health
+		code   int
+		body   string
+	}{
+		{
+			health: &dto.Health{Status: dto.HealthStatusOK, Checks: []*dto.HealthCheck{
+				{Name: "cache", Status: dto.HealthStatusOK, DurationMs: 1},
+			}},
+			code: http.StatusOK,
+			body: `{"status":"ok","checks":[{"name":"cache","status":"ok","duration_ms":1}]}`,
+		},
+		{
+			health: &dto.Health{Status: dto.HealthStatusFail, Checks: []*dto.HealthCheck{
+				{Name: "cache", Status: dto.HealthStatusFail, Error: "connection refused"},
+			}},
+			code: http.StatusServiceUnavailable,
+			body: `{"status":"fail","checks":[{"name":"cache","status":"fail","error":"connection refused","duration_ms":0}]}`,
+		},
+	}
+
+	for _, tc := range cases {
+		con, h := setupHealth()
+
+		gin.SetMode(gin.TestMode)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/readyz", nil)
+
+		h.On("Ready", mock.Anything).Return(tc.health)
+
+		con.Ready(c)
+
+		require.Equal(t, tc.code, w.Code)
+		require.JSONEq(t, tc.body, w.Body.String())
+	}
+}
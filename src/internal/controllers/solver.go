@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/domain/dto/requests"
+	"mortgage-calculator/src/internal/lib/money"
+	"net/http"
+)
+
+// Solver finds calculation params satisfying monthly payment limit.
+type Solver interface {
+	MaxLoan(ctx context.Context, payment money.Amount, params dto.CalcParams, program dto.CalcProgram) (*dto.Solution, error)
+	MinTerm(ctx context.Context, payment money.Amount, params dto.CalcParams, program dto.CalcProgram) (*dto.Solution, error)
+}
+
+// SolverController deals with reverse calculation endpoints.
+type SolverController struct {
+	log    *slog.Logger
+	solver Solver
+}
+
+// NewSolverController is a constructor for SolverController.
+func NewSolverController(
+	log *slog.Logger,
+	solver Solver,
+) *SolverController {
+	return &SolverController{
+		log:    log,
+		solver: solver,
+	}
+}
+
+type solveResponse struct {
+	Mode       string             `json:"mode"`
+	Program    dto.CalcProgram    `json:"program"`
+	Params     dto.CalcParams     `json:"params"`
+	Aggregates dto.CalcAggregates `json:"aggregates"`
+}
+
+// Solve validates request params and finds either maximum loan or minimal term for given monthly payment.
+func (con *SolverController) Solve(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// validate request
+	in, err := validateSolveRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	params := dto.CalcParams{
+		ObjectCost:     in.ObjectCost,
+		InitialPayment: in.InitialPayment,
+		Months:         in.Months,
+		PaymentType:    in.PaymentType,
+		StartDate:      in.StartDate,
+	}
+
+	var res *dto.Solution
+	switch in.Mode {
+	case requests.SolveModeMaxLoan:
+		res, err = con.solver.MaxLoan(ctx, in.MonthlyPayment, params, in.Program)
+	case requests.SolveModeMinTerm:
+		res, err = con.solver.MinTerm(ctx, in.MonthlyPayment, params, in.Program)
+	}
+
+	if err != nil {
+		writeCalcError(c, err)
+		return
+	}
+
+	// compose response
+	out := solveResponse{
+		Mode:       in.Mode,
+		Program:    in.Program,
+		Params:     res.Params,
+		Aggregates: res.Aggregates,
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+var errNoMonths = errors.New("months are required for max_loan mode")
+var errNoObjectCost = errors.New("object cost is required for min_term mode")
+var errNoLoan = errors.New("object cost should be more than initial payment")
+
+func validateSolveRequest(c *gin.Context) (*requests.SolveRequest, error) {
+	var in requests.SolveRequest
+	err := c.ShouldBindJSON(&in)
+
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errNoPayload
+		}
+		return nil, fmt.Errorf("%w: %s", errValidation, err.Error())
+	}
+
+	programCount := in.Program.Count()
+
+	// program must be specified
+	if programCount == 0 {
+		return nil, errNoProgram
+	}
+
+	// only one program must be selected
+	if programCount > 1 {
+		return nil, errTooManyPrograms
+	}
+
+	// every mode requires its own params
+	switch in.Mode {
+	case requests.SolveModeMaxLoan:
+		if in.Months == 0 {
+			return nil, errNoMonths
+		}
+	case requests.SolveModeMinTerm:
+		if in.ObjectCost == 0 {
+			return nil, errNoObjectCost
+		}
+		if in.ObjectCost <= in.InitialPayment {
+			return nil, errNoLoan
+		}
+	}
+
+	return &in, nil
+}
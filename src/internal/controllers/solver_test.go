@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/money"
+	servicesmock "mortgage-calculator/src/internal/mocks/services"
+	"mortgage-calculator/src/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setupSolver() (*SolverController, *servicesmock.MockSolver) {
+	service := new(servicesmock.MockSolver)
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	con := NewSolverController(log, service)
+
+	return con, service
+}
+
+func TestNewSolverController(t *testing.T) {
+	con, service := setupSolver()
+
+	require.NotEmpty(t, con)
+	require.Equal(t, service, con.solver)
+}
+
+func TestSolverController_Solve_MaxLoan(t *testing.T) {
+	con, s := setupSolver()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequest(
+		"POST",
+		"/solve",
+		bytes.NewBufferString(`{"mode":"max_loan","monthly_payment":100,"initial_payment":200,"months":12,"program":"base"}`),
+	)
+	c.Request = req
+
+	params := dto.CalcParams{InitialPayment: 200, Months: 12}
+	s.On("MaxLoan", mock.Anything, money.Amount(100), params, dto.CalcProgram{ID: "base"}).Return(&dto.Solution{
+		Params:     dto.CalcParams{ObjectCost: 1300, InitialPayment: 200, Months: 12},
+		Aggregates: dto.CalcAggregates{LoanSum: 1100, MonthlyPayment: 97},
+	}, nil)
+
+	con.Solve(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var out solveResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+	require.Equal(t, "max_loan", out.Mode)
+	require.Equal(t, dto.CalcProgram{ID: "base"}, out.Program)
+	require.Equal(t, money.Amount(1300), out.Params.ObjectCost)
+	require.Equal(t, money.Amount(1100), out.Aggregates.LoanSum)
+
+	s.AssertNotCalled(t, "MinTerm")
+}
+
+func TestSolverController_Solve_MinTerm(t *testing.T) {
+	con, s := setupSolver()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequest(
+		"POST",
+		"/solve",
+		bytes.NewBufferString(`{"mode":"min_term","monthly_payment":100,"object_cost":1000,"initial_payment":200,"program":{"salary":true}}`),
+	)
+	c.Request = req
+
+	params := dto.CalcParams{ObjectCost: 1000, InitialPayment: 200}
+	s.On("MinTerm", mock.Anything, money.Amount(100), params, dto.CalcProgram{Salary: true}).Return(&dto.Solution{
+		Params:     dto.CalcParams{ObjectCost: 1000, InitialPayment: 200, Months: 9},
+		Aggregates: dto.CalcAggregates{LoanSum: 800, MonthlyPayment: 92},
+	}, nil)
+
+	con.Solve(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var out solveResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+	require.Equal(t, "min_term", out.Mode)
+	require.Equal(t, 9, out.Params.Months)
+
+	s.AssertNotCalled(t, "MaxLoan")
+}
+
+func TestSolverController_Solve_PaymentTooLow(t *testing.T) {
+	con, s := setupSolver()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequest(
+		"POST",
+		"/solve",
+		bytes.NewBufferString(`{"mode":"min_term","monthly_payment":1,"object_cost":1000,"initial_payment":200,"program":"base"}`),
+	)
+	c.Request = req
+
+	s.On("MinTerm", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return((*dto.Solution)(nil), services.ErrPaymentTooLow)
+
+	con.Solve(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), services.ErrPaymentTooLow.Error())
+}
+
+func TestValidateSolveRequest_FailCases(t *testing.T) {
+	cases := []struct {
+		body string
+		err  error
+	}{
+		{``, errNoPayload},
+		{`{"mode":"max_payment","monthly_payment":100,"initial_payment":200,"months":12,"program":"base"}`, errValidation},
+		{`{"mode":"max_loan","initial_payment":200,"months":12,"program":"base"}`, errValidation},
+		{`{"mode":"max_loan","monthly_payment":100,"initial_payment":200,"months":12}`, errNoProgram},
+		{`{"mode":"max_loan","monthly_payment":100,"initial_payment":200,"months":12,"program":{"base":true,"salary":true}}`, errTooManyPrograms},
+		{`{"mode":"max_loan","monthly_payment":100,"initial_payment":200,"program":"base"}`, errNoMonths},
+		{`{"mode":"min_term","monthly_payment":100,"initial_payment":200,"program":"base"}`, errNoObjectCost},
+		{`{"mode":"min_term","monthly_payment":100,"object_cost":200,"initial_payment":200,"program":"base"}`, errNoLoan},
+	}
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+
+	for _, tt := range cases {
+		c, _ := gin.CreateTestContext(w)
+		req, _ := http.NewRequest("POST", "/solve", bytes.NewBufferString(tt.body))
+		c.Request = req
+		data, err := validateSolveRequest(c)
+
+		require.Error(t, err)
+		require.Empty(t, data)
+		require.ErrorIs(t, err, tt.err)
+	}
+}
@@ -0,0 +1,20 @@
+package dto
+
+import "mortgage-calculator/src/internal/lib/money"
+
+// Borrower describes borrower's monthly income and existing debt payments.
+type Borrower struct {
+	MonthlyIncome money.Amount `json:"monthly_income" binding:"required,min=1,max=1000000000000000"`
+	Obligations   money.Amount `json:"obligations" binding:"omitempty,min=0,max=1000000000000000"`
+}
+
+// Affordability represents debt-to-income check of calculation result.
+type Affordability struct {
+	MonthlyIncome   money.Amount `json:"monthly_income"`
+	Obligations     money.Amount `json:"obligations"`
+	Payment         money.Amount `json:"payment"` // Payment is the largest scheduled mortgage payment.
+	DebtToIncome    float64      `json:"debt_to_income"`
+	MaxDebtToIncome float64      `json:"max_debt_to_income"`
+	MaxPayment      money.Amount `json:"max_payment"` // MaxPayment is the largest mortgage payment fitting the threshold.
+	Affordable      bool         `json:"affordable"`
+}
@@ -1,9 +1,16 @@
 package dto
 
+import "time"
+
 // CacheEntry represents data stored in cache.
+// ExpiresAt is omitted for entries which never expire.
 type CacheEntry struct {
 	Aggregates *CalcAggregates `json:"aggregates"`
 	Params     *CalcParams     `json:"params"`
 	Program    *CalcProgram    `json:"program"`
 	ID         int64           `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ExpiresAt  *time.Time      `json:"expires_at,omitempty"`
+	Hits       int64           `json:"hits"`                  // Hits counts reads of entry since it was cached.
+	LastAccess *time.Time      `json:"last_access,omitempty"` // LastAccess is time of the last read.
 }
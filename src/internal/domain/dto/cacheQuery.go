@@ -0,0 +1,38 @@
+package dto
+
+import "mortgage-calculator/src/internal/lib/money"
+
+// Sort fields of cache entries.
+const (
+	CacheSortID        = "id"
+	CacheSortCreatedAt = "created_at"
+	CacheSortLoanSum   = "loan_sum"
+)
+
+// Sort orders.
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// CacheQuery represents filters, sorting and page of cache entries.
+// Zero value of any filter means that the filter is not applied.
+type CacheQuery struct {
+	Limit         int          `form:"limit" binding:"omitempty,min=1,max=1000"` // Limit defaults to 100.
+	Offset        int          `form:"offset" binding:"omitempty,min=0"`
+	Sort          string       `form:"sort" binding:"omitempty,oneof=id created_at loan_sum"` // Sort defaults to id.
+	Order         string       `form:"order" binding:"omitempty,oneof=asc desc"`              // Order defaults to asc.
+	Program       string       `form:"program"`
+	MinMonths     int          `form:"min_months" binding:"omitempty,min=1"`
+	MaxMonths     int          `form:"max_months" binding:"omitempty,min=1,gtefield=MinMonths"`
+	MinObjectCost money.Amount `form:"min_object_cost" binding:"omitempty,min=1"`
+	MaxObjectCost money.Amount `form:"max_object_cost" binding:"omitempty,min=1,gtefield=MinObjectCost"`
+}
+
+// CacheList represents page of cache entries.
+type CacheList struct {
+	Total   int           `json:"total"` // Total is a number of entries matching filters.
+	Limit   int           `json:"limit"`
+	Offset  int           `json:"offset"`
+	Entries []*CacheEntry `json:"entries"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+// CacheStats represents cache usage statistics.
+type CacheStats struct {
+	Hits        int64            `json:"hits"`
+	Misses      int64            `json:"misses"`
+	Sets        int64            `json:"sets"`
+	Coalesced   int64            `json:"coalesced"` // Coalesced counts misses served by calculation of concurrent request.
+	Evictions   int64            `json:"evictions"`
+	Expirations int64            `json:"expirations"`
+	HitRatio    float64          `json:"hit_ratio"`
+	Entries     int              `json:"entries"`
+	TopRequests []*RequestCount  `json:"top_requests"` // TopRequests lists the most requested params, most requested first.
+	Programs    map[string]int64 `json:"programs"`     // Programs counts requests by program id.
+	Terms       map[int]int64    `json:"terms"`        // Terms counts requests by months.
+}
+
+// RequestCount represents how many times params were requested.
+type RequestCount struct {
+	Params  *CalcParams  `json:"params"`
+	Program *CalcProgram `json:"program"`
+	Count   int64        `json:"count"`
+}
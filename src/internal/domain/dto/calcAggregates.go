@@ -1,11 +1,20 @@
 // Package dto provides dtos used across whole domain.
 package dto
 
+import "mortgage-calculator/src/internal/lib/money"
+
 // CalcAggregates represents calculation result.
 type CalcAggregates struct {
-	LastPaymentDate string `json:"last_payment_date"`
-	Rate            int    `json:"rate"`
-	LoanSum         int    `json:"loan_sum"`
-	MonthlyPayment  int    `json:"monthly_payment"`
-	Overpayment     int    `json:"overpayment"`
+	LastPaymentDate string         `json:"last_payment_date"`
+	PaymentType     string         `json:"payment_type"`
+	Currency        money.Currency `json:"currency"` // Currency describes minor units of all amounts.
+	Rate            float64        `json:"rate"`     // Rate is annual interest rate in percents, e.g. 8.5.
+	RateBps         money.Rate     `json:"rate_bps"` // RateBps is annual interest rate in basis points, e.g. 850.
+	LoanSum         money.Amount   `json:"loan_sum"`
+	MonthlyPayment  money.Amount   `json:"monthly_payment"` // MonthlyPayment equals FirstPayment for differentiated payments.
+	FirstPayment    money.Amount   `json:"first_payment"`
+	LastPayment     money.Amount   `json:"last_payment"`
+	MaxPayment      money.Amount   `json:"max_payment"`
+	Overpayment     money.Amount   `json:"overpayment"`
+	SavedInterest   money.Amount   `json:"saved_interest,omitempty"` // SavedInterest is overpayment decrease caused by early repayments.
 }
@@ -1,8 +1,45 @@
 package dto
 
+import "mortgage-calculator/src/internal/lib/money"
+
+// PaymentTypeAnnuity is a payment type with equal monthly payments.
+// PaymentTypeDifferentiated is a payment type with equal principal parts and declining interest.
+const (
+	PaymentTypeAnnuity        = "annuity"
+	PaymentTypeDifferentiated = "differentiated"
+)
+
+// StrategyReduceTerm keeps payment and shortens the term after early repayment.
+// StrategyReducePayment keeps the term and decreases payment after early repayment.
+const (
+	StrategyReduceTerm    = "reduce_term"
+	StrategyReducePayment = "reduce_payment"
+)
+
+// MaxMonths limits term of every calculation, schedule is built month by month, so its size is bounded by the term.
+// It is duplicated in binding tags of requests.
+const MaxMonths = 1200
+
+// MaxAmount limits amounts of requests in minor units, so calculated amounts fit money.Amount for sane rates.
+// It is duplicated in binding tags of requests.
+const MaxAmount = 1_000_000_000_000_000
+
+// DateFormat is a format of dates in requests and results.
+const DateFormat = "2006-01-02"
+
 // CalcParams represent parameters required for calculation.
 type CalcParams struct {
-	ObjectCost     int `json:"object_cost" binding:"required"`
-	InitialPayment int `json:"initial_payment" binding:"required"`
-	Months         int `json:"months" binding:"required"`
+	ObjectCost      money.Amount     `json:"object_cost" binding:"required,min=1,max=1000000000000000"`
+	InitialPayment  money.Amount     `json:"initial_payment" binding:"required,min=0,max=1000000000000000"`
+	Months          int              `json:"months" binding:"required,min=1,max=1200"`
+	PaymentType     string           `json:"payment_type,omitempty" binding:"omitempty,oneof=annuity differentiated"` // PaymentType defaults to annuity.
+	EarlyRepayments []EarlyRepayment `json:"early_repayments,omitempty" binding:"omitempty,dive"`
+	StartDate       string           `json:"start_date,omitempty" binding:"omitempty,datetime=2006-01-02"` // StartDate is issue date, payments are made monthly after it.
+}
+
+// EarlyRepayment represents partial repayment made together with the regular payment of the month.
+type EarlyRepayment struct {
+	Month    int          `json:"month" binding:"required,min=1"`
+	Amount   money.Amount `json:"amount" binding:"required,min=1,max=1000000000000000"`
+	Strategy string       `json:"strategy" binding:"required,oneof=reduce_term reduce_payment"`
 }
@@ -1,8 +1,77 @@
 package dto
 
-// CalcProgram represents available programs for calculation.
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Ids of programs that can be chosen with legacy boolean form.
+const (
+	ProgramSalary   = "salary"
+	ProgramMilitary = "military"
+	ProgramBase     = "base"
+)
+
+// CalcProgram represents program chosen for calculation.
+// Program is chosen either by id (`"program": "salary"`) or by legacy boolean form (`"program": {"salary": true}`).
 type CalcProgram struct {
-	Salary   bool `json:"salary,omitempty"`
-	Military bool `json:"military,omitempty"`
-	Base     bool `json:"base,omitempty"`
+	ID       string `json:"-"`
+	Salary   bool   `json:"salary,omitempty"`
+	Military bool   `json:"military,omitempty"`
+	Base     bool   `json:"base,omitempty"`
+}
+
+type calcProgramFlags CalcProgram
+
+// MarshalJSON encodes program in the same form it was chosen.
+func (p CalcProgram) MarshalJSON() ([]byte, error) {
+	if p.ID != "" {
+		return json.Marshal(p.ID) //nolint:wrapcheck // called by encoding/json
+	}
+
+	return json.Marshal(calcProgramFlags(p)) //nolint:wrapcheck // called by encoding/json
+}
+
+// UnmarshalJSON decodes program from either id string or legacy boolean form.
+func (p *CalcProgram) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		*p = CalcProgram{}
+		return json.Unmarshal(data, &p.ID) //nolint:wrapcheck // called by encoding/json
+	}
+
+	var flags calcProgramFlags
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return err //nolint:wrapcheck // called by encoding/json
+	}
+	*p = CalcProgram(flags)
+
+	return nil
+}
+
+// Count returns number of chosen programs.
+func (p CalcProgram) Count() int {
+	var res int
+	for _, chosen := range []bool{p.ID != "", p.Salary, p.Military, p.Base} {
+		if chosen {
+			res++
+		}
+	}
+
+	return res
+}
+
+// Key returns id of chosen program or empty string if none is chosen.
+func (p CalcProgram) Key() string {
+	switch {
+	case p.ID != "":
+		return p.ID
+	case p.Salary:
+		return ProgramSalary
+	case p.Military:
+		return ProgramMilitary
+	case p.Base:
+		return ProgramBase
+	default:
+		return ""
+	}
 }
@@ -0,0 +1,29 @@
+package dto
+
+import "mortgage-calculator/src/internal/lib/money"
+
+// SchedulePayment represents single period of amortization schedule.
+type SchedulePayment struct {
+	Month          int          `json:"month"`
+	PaymentDate    string       `json:"payment_date"`
+	Payment        money.Amount `json:"payment"`
+	Principal      money.Amount `json:"principal"`
+	Interest       money.Amount `json:"interest"`
+	Balance        money.Amount `json:"balance"` // Balance is the debt remaining after the payment and early repayment.
+	EarlyRepayment money.Amount `json:"early_repayment,omitempty"`
+}
+
+// ScheduleTotals represents sums of all schedule payments.
+type ScheduleTotals struct {
+	Payment        money.Amount `json:"payment"`
+	Principal      money.Amount `json:"principal"`
+	Interest       money.Amount `json:"interest"`
+	EarlyRepayment money.Amount `json:"early_repayment,omitempty"`
+}
+
+// CalcSchedule represents calculation result with month-by-month breakdown.
+type CalcSchedule struct {
+	Aggregates CalcAggregates    `json:"aggregates"`
+	Payments   []SchedulePayment `json:"payments"`
+	Totals     ScheduleTotals    `json:"totals"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+// Health statuses.
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// Health represents status of application and its components.
+type Health struct {
+	Status string         `json:"status"`
+	Checks []*HealthCheck `json:"checks,omitempty"` // Checks are sorted by name.
+}
+
+// HealthCheck represents status of single component.
+type HealthCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+import "mortgage-calculator/src/internal/lib/money"
+
+// Program describes conditions of mortgage program.
+// Zero value of any limit means that the limit is not applied.
+type Program struct {
+	ID                     string       `json:"id"`
+	Name                   string       `json:"name"`
+	Rate                   float64      `json:"rate"` // Rate is annual interest rate, e.g. 0.08 for 8%.
+	MinInitialPaymentRatio float64      `json:"min_initial_payment_ratio"`
+	MinMonths              int          `json:"min_months"`
+	MaxMonths              int          `json:"max_months"`
+	MinLoan                money.Amount `json:"min_loan"`
+	MaxLoan                money.Amount `json:"max_loan"`
+	MaxObjectCost          money.Amount `json:"max_object_cost"`
+	MaxDebtToIncome        float64      `json:"max_debt_to_income"` // MaxDebtToIncome is affordability threshold, e.g. 0.5 for 50%.
+}
@@ -0,0 +1,7 @@
+package requests
+
+// CacheStatsRequest represents query of CacheStats endpoint.
+// Zero Top means default number of the most requested params.
+type CacheStatsRequest struct {
+	Top int `form:"top" binding:"omitempty,min=1,max=100"`
+}
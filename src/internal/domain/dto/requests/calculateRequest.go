@@ -6,5 +6,6 @@ import "mortgage-calculator/src/internal/domain/dto"
 // CalculateRequest represents payload for Calculate endpoint.
 type CalculateRequest struct {
 	dto.CalcParams
-	Program dto.CalcProgram `json:"program" binding:"required"`
+	Program  dto.CalcProgram `json:"program" binding:"required"`
+	Borrower *dto.Borrower   `json:"borrower,omitempty"`
 }
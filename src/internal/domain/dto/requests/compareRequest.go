@@ -0,0 +1,10 @@
+package requests
+
+import "mortgage-calculator/src/internal/domain/dto"
+
+// CompareRequest represents payload for Compare endpoint.
+// Empty Programs means all available programs.
+type CompareRequest struct {
+	dto.CalcParams
+	Programs []dto.CalcProgram `json:"programs,omitempty"`
+}
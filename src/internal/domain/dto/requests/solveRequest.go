@@ -0,0 +1,25 @@
+package requests
+
+import (
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/money"
+)
+
+// SolveModeMaxLoan finds maximum loan sum and object cost for given monthly payment, initial payment and term.
+// SolveModeMinTerm finds minimal term for given monthly payment, object cost and initial payment.
+const (
+	SolveModeMaxLoan = "max_loan"
+	SolveModeMinTerm = "min_term"
+)
+
+// SolveRequest represents payload for Solve endpoint.
+type SolveRequest struct {
+	Mode           string          `json:"mode" binding:"required,oneof=max_loan min_term"`
+	MonthlyPayment money.Amount    `json:"monthly_payment" binding:"required,min=1,max=1000000000000000"` // MonthlyPayment limits the maximum payment.
+	ObjectCost     money.Amount    `json:"object_cost,omitempty" binding:"omitempty,min=1,max=1000000000000000"`
+	InitialPayment money.Amount    `json:"initial_payment" binding:"required,min=1,max=1000000000000000"`
+	Months         int             `json:"months,omitempty" binding:"omitempty,min=1,max=1200"`
+	PaymentType    string          `json:"payment_type,omitempty" binding:"omitempty,oneof=annuity differentiated"`
+	StartDate      string          `json:"start_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	Program        dto.CalcProgram `json:"program" binding:"required"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+// Solution represents params found by reverse calculation and their aggregates.
+type Solution struct {
+	Params     CalcParams     `json:"params"`
+	Aggregates CalcAggregates `json:"aggregates"`
+}
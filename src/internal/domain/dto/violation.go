@@ -0,0 +1,10 @@
+package dto
+
+// Violation describes program rule that calculation params do not satisfy.
+type Violation struct {
+	Code    string  `json:"code"`
+	Field   string  `json:"field"`
+	Message string  `json:"message"`
+	Limit   float64 `json:"limit"`
+	Actual  float64 `json:"actual"`
+}
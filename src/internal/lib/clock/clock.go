@@ -0,0 +1,25 @@
+// Package clock provides source of current time which can be pinned in tests.
+package clock
+
+import "time"
+
+// Clock returns current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// System is a clock returning system time.
+type System struct{}
+
+// Now returns current system time.
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// Fixed is a clock always returning the same time.
+type Fixed time.Time
+
+// Now returns pinned time.
+func (c Fixed) Now() time.Time {
+	return time.Time(c)
+}
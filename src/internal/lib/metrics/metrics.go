@@ -0,0 +1,346 @@
+// Package metrics provides registry of metrics exposed in Prometheus text format.
+// It implements counters, histograms and metrics collected on scrape, without third-party libraries.
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Type is a type of metric family.
+type Type string
+
+// Metric types.
+const (
+	TypeCounter   Type = "counter"
+	TypeGauge     Type = "gauge"
+	TypeHistogram Type = "histogram"
+)
+
+// DefaultBuckets are upper bounds of histogram buckets for durations in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Label is a name and value of metric dimension.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a value of metric with labels.
+type Sample struct {
+	Name   string // Name overrides family name, histograms use it for buckets, sum and count.
+	Labels []Label
+	Value  float64
+}
+
+// Family is a group of samples of one metric.
+type Family struct {
+	Name    string
+	Help    string
+	Type    Type
+	Samples []Sample
+}
+
+// Collector produces metric families on every scrape.
+type Collector interface {
+	Collect(ctx context.Context) ([]*Family, error)
+}
+
+// Registry keeps collectors and writes their metrics.
+type Registry struct {
+	log        *slog.Logger
+	mu         sync.RWMutex
+	collectors []Collector
+}
+
+// NewRegistry is a constructor for Registry.
+func NewRegistry(log *slog.Logger) *Registry {
+	return &Registry{log: log}
+}
+
+// Register adds collector, its families are written in order of registration.
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+// NewCounterVec creates and registers counter partitioned by labels.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec: newVec(name, help, labels)}
+	r.Register(c)
+
+	return c
+}
+
+// NewHistogramVec creates and registers histogram partitioned by labels.
+// Nil buckets mean DefaultBuckets.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+
+	h := &HistogramVec{vec: newVec(name, help, labels), buckets: buckets}
+	r.Register(h)
+
+	return h
+}
+
+// Write writes metrics of all collectors in text exposition format.
+// Failed collector is skipped, so single broken component doesn't hide other metrics.
+func (r *Registry) Write(ctx context.Context, w io.Writer) error {
+	const op = "metrics.Registry.Write"
+
+	r.mu.RLock()
+	collectors := make([]Collector, len(r.collectors))
+	copy(collectors, r.collectors)
+	r.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		families, err := c.Collect(ctx)
+		if err != nil {
+			r.log.Warn("failed to collect metrics", slog.String("op", op), slog.Any("error", err))
+			continue
+		}
+
+		for _, f := range families {
+			writeFamily(bw, f)
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Handler returns http handler exposing metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		if err := r.Write(req.Context(), w); err != nil {
+			r.log.Error("failed to write metrics", slog.Any("error", err))
+		}
+	})
+}
+
+// CounterVec is a counter partitioned by labels.
+type CounterVec struct {
+	vec
+}
+
+// Inc increments counter of label values by one.
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds non-negative v to counter of label values.
+func (c *CounterVec) Add(v float64, values ...string) {
+	if v < 0 {
+		return
+	}
+
+	c.update(values, func(s *series) { s.value += v })
+}
+
+// Collect returns current values of counter.
+func (c *CounterVec) Collect(_ context.Context) ([]*Family, error) {
+	f := &Family{Name: c.name, Help: c.help, Type: TypeCounter}
+
+	c.each(func(s *series) {
+		f.Samples = append(f.Samples, Sample{Labels: c.labelsOf(s.values), Value: s.value})
+	})
+
+	return []*Family{f}, nil
+}
+
+// HistogramVec is a histogram partitioned by labels.
+type HistogramVec struct {
+	vec
+	buckets []float64
+}
+
+// Observe adds v to histogram of label values.
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	h.update(values, func(s *series) {
+		if s.counts == nil {
+			s.counts = make([]uint64, len(h.buckets))
+		}
+
+		for i, bound := range h.buckets {
+			if v <= bound {
+				s.counts[i]++
+			}
+		}
+
+		s.count++
+		s.value += v
+	})
+}
+
+// Collect returns cumulative buckets, sum and count of histogram.
+func (h *HistogramVec) Collect(_ context.Context) ([]*Family, error) {
+	f := &Family{Name: h.name, Help: h.help, Type: TypeHistogram}
+
+	h.each(func(s *series) {
+		labels := h.labelsOf(s.values)
+
+		for i, bound := range h.buckets {
+			f.Samples = append(f.Samples, Sample{
+				Name:   h.name + "_bucket",
+				Labels: append(labels[:len(labels):len(labels)], Label{Name: "le", Value: formatFloat(bound)}),
+				Value:  float64(s.counts[i]),
+			})
+		}
+
+		f.Samples = append(f.Samples,
+			Sample{
+				Name:   h.name + "_bucket",
+				Labels: append(labels[:len(labels):len(labels)], Label{Name: "le", Value: "+Inf"}),
+				Value:  float64(s.count),
+			},
+			Sample{Name: h.name + "_sum", Labels: labels, Value: s.value},
+			Sample{Name: h.name + "_count", Labels: labels, Value: float64(s.count)},
+		)
+	})
+
+	return []*Family{f}, nil
+}
+
+// vec keeps series of metric by label values.
+type vec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	values []string
+	value  float64 // value is a counter value or sum of histogram observations
+	count  uint64
+	counts []uint64
+}
+
+func newVec(name, help string, labels []string) vec {
+	return vec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		series: make(map[string]*series),
+	}
+}
+
+// update applies fn to series of label values, missing values are empty and extra ones are ignored.
+func (v *vec) update(values []string, fn func(s *series)) {
+	values = append(values[:0:0], values...)
+	for len(values) < len(v.labels) {
+		values = append(values, "")
+	}
+	values = values[:len(v.labels)]
+
+	key := strings.Join(values, "\xff")
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{values: values}
+		v.series[key] = s
+	}
+
+	fn(s)
+}
+
+// each calls fn for every series ordered by label values.
+func (v *vec) each(fn func(s *series)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fn(v.series[k])
+	}
+}
+
+func (v *vec) labelsOf(values []string) []Label {
+	res := make([]Label, len(v.labels))
+	for i, name := range v.labels {
+		res[i] = Label{Name: name, Value: values[i]}
+	}
+
+	return res
+}
+
+func writeFamily(w *bufio.Writer, f *Family) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.Name, f.Type)
+
+	for _, s := range f.Samples {
+		name := s.Name
+		if name == "" {
+			name = f.Name
+		}
+
+		w.WriteString(name)
+
+		if len(s.Labels) > 0 {
+			w.WriteByte('{')
+			for i, l := range s.Labels {
+				if i > 0 {
+					w.WriteByte(',')
+				}
+				fmt.Fprintf(w, "%s=\"%s\"", l.Name, escapeLabel(l.Value))
+			}
+			w.WriteByte('}')
+		}
+
+		fmt.Fprintf(w, " %s\n", formatFloat(s.Value))
+	}
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
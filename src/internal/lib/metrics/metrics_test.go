@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setup() *Registry {
+	return NewRegistry(slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})))
+}
+
+func write(t *testing.T, r *Registry) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Write(context.Background(), &buf))
+
+	return buf.String()
+}
+
+func TestCounterVec(t *testing.T) {
+	r := setup()
+	c := r.NewCounterVec("requests_total", "Number of requests.", "method", "status")
+
+	c.Inc("POST", "200")
+	c.Inc("POST", "200")
+	c.Add(3, "GET", "404")
+	c.Add(-1, "GET", "404")
+	c.Inc("GET") // missing values are empty
+
+	require.Equal(t, `# HELP requests_total Number of requests.
+# TYPE requests_total counter
+requests_total{method="GET",status=""} 1
+requests_total{method="GET",status="404"} 3
+requests_total{method="POST",status="200"} 2
+`, write(t, r))
+}
+
+func TestHistogramVec(t *testing.T) {
+	r := setup()
+	h := r.NewHistogramVec("duration_seconds", "Duration.", []float64{0.1, 1}, "route")
+
+	h.Observe(0.05, "/execute")
+	h.Observe(0.5, "/execute")
+	h.Observe(5, "/execute")
+
+	require.Equal(t, `# HELP duration_seconds Duration.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{route="/execute",le="0.1"} 1
+duration_seconds_bucket{route="/execute",le="1"} 2
+duration_seconds_bucket{route="/execute",le="+Inf"} 3
+duration_seconds_sum{route="/execute"} 5.55
+duration_seconds_count{route="/execute"} 3
+`, write(t, r))
+}
+
+type collectorFunc func(ctx context.Context) ([]*Family, error)
+
+func (f collectorFunc) Collect(ctx context.Context) ([]*Family, error) {
+	return f(ctx)
+}
+
+func TestRegistry_Collector(t *testing.T) {
+	r := setup()
+
+	r.Register(collectorFunc(func(context.Context) ([]*Family, error) {
+		return nil, errors.New("backend is unavailable")
+	}))
+	r.Register(collectorFunc(func(context.Context) ([]*Family, error) {
+		return []*Family{{
+			Name:    "cache_entries",
+			Help:    "Entries\nin \\ cache.",
+			Type:    TypeGauge,
+			Samples: []Sample{{Labels: []Label{{Name: "driver", Value: "a\"b\\c\nd"}}, Value: 2}},
+		}}, nil
+	}))
+
+	require.Equal(t, `# HELP cache_entries Entries\nin \\ cache.
+# TYPE cache_entries gauge
+cache_entries{driver="a\"b\\c\nd"} 2
+`, write(t, r))
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := setup()
+	r.NewCounterVec("requests_total", "Number of requests.").Inc()
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Contains(t, w.Body.String(), "requests_total 1\n")
+}
@@ -0,0 +1,123 @@
+// Package money provides fixed-point arithmetic for monetary amounts.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Amount is a monetary amount in minor units of currency, e.g. kopecks for RUB with 2 minor units.
+type Amount int64
+
+// Currency describes currency code and number of digits of its minor unit.
+type Currency struct {
+	Code       string `json:"code"`
+	MinorUnits int    `json:"minor_units"`
+}
+
+// Rounding is a mode of rounding fractional minor units.
+type Rounding string
+
+// Supported rounding modes.
+const (
+	RoundUp       Rounding = "up"        // RoundUp rounds towards positive infinity.
+	RoundDown     Rounding = "down"      // RoundDown rounds towards negative infinity.
+	RoundHalfUp   Rounding = "half_up"   // RoundHalfUp rounds to nearest, halves away from zero.
+	RoundHalfEven Rounding = "half_even" // RoundHalfEven rounds to nearest, halves to even (banker's rounding).
+)
+
+// ErrUnknownRounding represents error when rounding mode is not supported.
+var ErrUnknownRounding = errors.New("unknown rounding mode")
+
+// ErrOverflow represents error when result of calculation doesn't fit Amount.
+var ErrOverflow = errors.New("amount is out of range")
+
+// ParseRounding validates rounding mode name.
+func ParseRounding(s string) (Rounding, error) {
+	switch r := Rounding(s); r {
+	case RoundUp, RoundDown, RoundHalfUp, RoundHalfEven:
+		return r, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownRounding, s)
+	}
+}
+
+// Add returns a + b, ErrOverflow is returned when the sum doesn't fit Amount.
+func Add(a, b Amount) (Amount, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, ErrOverflow
+	}
+
+	return a + b, nil
+}
+
+// MulDiv calculates a * num / den without intermediate overflow and rounds result with given mode.
+// Denominator must be positive, ErrOverflow is returned when result doesn't fit Amount.
+func MulDiv(a Amount, num, den int64, mode Rounding) (Amount, error) {
+	n := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(num))
+	return Quo(n, big.NewInt(den), mode)
+}
+
+// Quo divides num by den and rounds result with given mode.
+// Denominator must be positive, ErrOverflow is returned when result doesn't fit Amount.
+func Quo(num, den *big.Int, mode Rounding) (Amount, error) {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() != 0 {
+		q.Add(q, big.NewInt(roundingStep(q, r, den, mode)))
+	}
+
+	if !q.IsInt64() {
+		return 0, ErrOverflow
+	}
+
+	return Amount(q.Int64()), nil
+}
+
+// roundingStep returns correction of truncated quotient q with non-zero remainder r.
+func roundingStep(q, r, den *big.Int, mode Rounding) int64 {
+	sign := int64(r.Sign())
+
+	switch mode {
+	case RoundUp:
+		return max(sign, 0)
+	case RoundDown:
+		return min(sign, 0)
+	case RoundHalfEven:
+		switch cmpHalf(r, den) {
+		case 1:
+			return sign
+		case 0:
+			return sign * int64(q.Bit(0))
+		}
+	default:
+		if cmpHalf(r, den) >= 0 {
+			return sign
+		}
+	}
+
+	return 0
+}
+
+// cmpHalf compares absolute value of remainder r with the half of denominator.
+func cmpHalf(r, den *big.Int) int {
+	twice := new(big.Int).Abs(r)
+	return twice.Lsh(twice, 1).Cmp(den)
+}
+
+// Rate is an annual interest rate in basis points, e.g. 850 for 8.5%.
+type Rate int64
+
+// BasisPoints is a number of basis points in a whole.
+const BasisPoints = 10000
+
+// NewRate converts fractional rate, e.g. 0.085, to basis points.
+func NewRate(rate float64) Rate {
+	return Rate(math.Round(rate * BasisPoints))
+}
+
+// Percent returns rate in percents, e.g. 8.5.
+func (r Rate) Percent() float64 {
+	return float64(r) / 100
+}
@@ -0,0 +1,98 @@
+package money
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestParseRounding(t *testing.T) {
+	for _, s := range []string{"up", "down", "half_up", "half_even"} {
+		r, err := ParseRounding(s)
+		require.NoError(t, err)
+		require.Equal(t, Rounding(s), r)
+	}
+
+	r, err := ParseRounding("ceil")
+	require.ErrorIs(t, err, ErrUnknownRounding)
+	require.Empty(t, r)
+}
+
+func TestQuo(t *testing.T) {
+	cases := []struct {
+		num, den int64
+		mode     Rounding
+		want     Amount
+	}{
+		{10, 5, RoundUp, 2},
+		{11, 5, RoundUp, 3},
+		{-11, 5, RoundUp, -2},
+		{14, 5, RoundDown, 2},
+		{-11, 5, RoundDown, -3},
+		{12, 5, RoundHalfUp, 2},
+		{5, 2, RoundHalfUp, 3},
+		{-5, 2, RoundHalfUp, -3},
+		{5, 2, RoundHalfEven, 2},
+		{7, 2, RoundHalfEven, 4},
+		{-5, 2, RoundHalfEven, -2},
+		{13, 5, RoundHalfEven, 3},
+	}
+
+	for _, tt := range cases {
+		res, err := Quo(big.NewInt(tt.num), big.NewInt(tt.den), tt.mode)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, res, "%d/%d %s", tt.num, tt.den, tt.mode)
+	}
+
+	// quotient doesn't fit int64
+	num := new(big.Int).Lsh(big.NewInt(1), 64)
+	_, err := Quo(num, big.NewInt(1), RoundUp)
+	require.ErrorIs(t, err, ErrOverflow)
+
+	// rounding step moves quotient out of range
+	num = new(big.Int).Mul(big.NewInt(math.MaxInt64), big.NewInt(2))
+	_, err = Quo(num.Add(num, big.NewInt(1)), big.NewInt(2), RoundUp)
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestAdd(t *testing.T) {
+	res, err := Add(2, 3)
+	require.NoError(t, err)
+	require.Equal(t, Amount(5), res)
+
+	res, err = Add(math.MaxInt64, -1)
+	require.NoError(t, err)
+	require.Equal(t, Amount(math.MaxInt64-1), res)
+
+	_, err = Add(math.MaxInt64, 1)
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = Add(math.MinInt64, -1)
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestMulDiv(t *testing.T) {
+	mulDiv := func(a Amount, num, den int64, mode Rounding) Amount {
+		res, err := MulDiv(a, num, den, mode)
+		require.NoError(t, err)
+		return res
+	}
+
+	// interest of one month at 8.5% annual rate
+	require.Equal(t, Amount(2833333), mulDiv(400000000, 850, 12*BasisPoints, RoundHalfUp))
+	require.Equal(t, Amount(2833334), mulDiv(400000000, 850, 12*BasisPoints, RoundUp))
+
+	// intermediate product doesn't fit int64
+	require.Equal(t, Amount(1<<62), mulDiv(1<<62, 1<<40, 1<<40, RoundDown))
+
+	_, err := MulDiv(1<<62, 4, 1, RoundDown)
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestRate(t *testing.T) {
+	require.Equal(t, Rate(850), NewRate(0.085))
+	require.Equal(t, Rate(2900), NewRate(0.29))
+	require.Equal(t, 8.5, NewRate(0.085).Percent())
+	require.Equal(t, 8.0, NewRate(0.08).Percent())
+}
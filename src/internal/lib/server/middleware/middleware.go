@@ -2,28 +2,177 @@
 package middleware
 
 import (
-	"fmt"
+	"crypto/rand"
+	"encoding/hex"
 	"github.com/gin-gonic/gin"
 	"log/slog"
+	"mortgage-calculator/src/internal/lib/metrics"
+	"mortgage-calculator/src/internal/lib/tracing"
+	"mortgage-calculator/src/internal/logger"
+	"net/http"
+	"strconv"
 	"time"
 )
 
-// Logger logs time, status code and duration of every request.
+// unmatchedRoute labels requests which don't match any route, so arbitrary paths don't create new series.
+const unmatchedRoute = "unmatched"
+
+// otherMethod labels requests of nonstandard methods, so arbitrary methods don't create new series.
+const otherMethod = "other"
+
+// RequestIDHeader carries id correlating request with its log lines.
+// Id of incoming request is kept, so it is shared with upstream services, otherwise new one is generated.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen limits length of incoming request id, longer ids are replaced.
+const maxRequestIDLen = 128
+
+// Logger assigns id to every request, passes logger with request attributes to handlers through request context
+// and logs method, path, client ip, status code and duration when request is handled.
 func Logger(
 	log *slog.Logger,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		t := time.Now()
 
+		id := requestID(c.GetHeader(RequestIDHeader))
+		c.Header(RequestIDHeader, id)
+
+		reqLog := log.With(slog.String("request_id", id))
+		if sc := tracing.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+			reqLog = reqLog.With(slog.String("trace_id", sc.TraceID.String()), slog.String("span_id", sc.SpanID.String()))
+		}
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), reqLog))
+
 		c.Next()
 
-		log.Info(
-			fmt.Sprintf(
-				"%s status_code: %d, duration: %d ns",
-				t.Format("2006/01/02 03:04:05"),
-				c.Writer.Status(),
-				time.Since(t).Nanoseconds(),
-			),
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("status", status),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.Duration("duration", time.Since(t)),
+		}
+		if query := c.Request.URL.RawQuery; query != "" {
+			attrs = append(attrs, slog.String("query", query))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		reqLog.LogAttrs(c.Request.Context(), level, "request handled", attrs...)
+	}
+}
+
+// requestID returns incoming id if it is safe to log, otherwise generates new one.
+func requestID(incoming string) string {
+	if validRequestID(incoming) {
+		return incoming
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// validRequestID reports whether id is non-empty, not too long and contains only visible ascii characters.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for i := range len(id) {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Tracing starts server span of every request as a child of caller's span given in traceparent header
+// and passes it to handlers through request context.
+func Tracing(
+	tracer *tracing.Tracer,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if sc, err := tracing.ParseTraceparent(c.GetHeader(tracing.TraceparentHeader)); err == nil {
+			ctx = tracing.ContextWithRemoteSpanContext(ctx, sc)
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route, tracing.KindServer,
+			tracing.String("http.request.method", c.Request.Method),
+			tracing.String("http.route", route),
+			tracing.String("url.path", c.Request.URL.Path),
 		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(tracing.TraceresponseHeader, span.SpanContext().Traceparent())
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(tracing.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(tracing.StatusError, http.StatusText(status))
+		}
+	}
+}
+
+// Metrics registers http metrics and counts every request and its duration by method, route and status.
+func Metrics(
+	reg *metrics.Registry,
+) gin.HandlerFunc {
+	requests := reg.NewCounterVec(
+		"http_requests_total", "Number of handled http requests.",
+		"method", "route", "status",
+	)
+	durations := reg.NewHistogramVec(
+		"http_request_duration_seconds", "Duration of http requests handling in seconds.", nil,
+		"method", "route", "status",
+	)
+
+	return func(c *gin.Context) {
+		t := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := methodLabel(c.Request.Method)
+		status := strconv.Itoa(c.Writer.Status())
+
+		requests.Inc(method, route, status)
+		durations.Observe(time.Since(t).Seconds(), method, route, status)
+	}
+}
+
+// methodLabel returns method for metric label, methods not defined by HTTP are replaced with otherMethod.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return otherMethod
 	}
 }
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"mortgage-calculator/src/internal/lib/metrics"
+	"mortgage-calculator/src/internal/lib/tracing"
+	"mortgage-calculator/src/internal/logger"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reg := metrics.NewRegistry(slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})))
+	r := gin.New()
+	r.Use(Metrics(reg))
+	r.GET("cache/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, path := range []string{"/cache/1", "/cache/2", "/unknown/path"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("FOO", "/cache/1", nil))
+
+	var buf bytes.Buffer
+	require.NoError(t, reg.Write(context.Background(), &buf))
+
+	require.Contains(t, buf.String(), `http_requests_total{method="GET",route="/cache/:id",status="204"} 2`)
+	require.Contains(t, buf.String(), `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	require.Contains(t, buf.String(), `http_requests_total{method="other",route="unmatched",status="404"} 1`)
+	require.NotContains(t, buf.String(), `FOO`)
+	require.Contains(t, buf.String(), `http_request_duration_seconds_count{method="GET",route="/cache/:id",status="204"} 2`)
+}
+
+func TestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{}))
+
+	r := gin.New()
+	r.Use(Logger(log))
+	r.GET("execute", func(c *gin.Context) {
+		logger.FromContext(c.Request.Context(), log).Info("calculated")
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		incoming string
+		expected string
+	}{
+		{name: "propagated", incoming: "upstream-42", expected: "upstream-42"},
+		{name: "generated", incoming: ""},
+		{name: "unsafe replaced", incoming: "id\nlevel=ERROR"},
+		{name: "too long replaced", incoming: strings.Repeat("a", maxRequestIDLen+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+
+			req := httptest.NewRequest(http.MethodGet, "/execute?debug=1", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			if tt.expected != "" {
+				require.Equal(t, tt.expected, id)
+			} else {
+				require.Len(t, id, 32)
+			}
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			require.Len(t, lines, 2)
+			for _, line := range lines {
+				require.Contains(t, line, "request_id="+id)
+			}
+			require.Contains(t, lines[0], "msg=calculated")
+			require.Contains(t, lines[1], "method=GET path=/execute client_ip=192.0.2.1 status=200")
+			require.Contains(t, lines[1], "query=\"debug=1\"")
+		})
+	}
+}
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{}))
+	tracer := tracing.NewTracer(log, tracing.NewWriterExporter(&buf), tracing.Options{})
+
+	var sc tracing.SpanContext
+
+	r := gin.New()
+	r.Use(Tracing(tracer), Logger(log))
+	r.GET("cache/:id", func(c *gin.Context) {
+		sc = tracing.SpanContextFromContext(c.Request.Context())
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/cache/1", nil)
+	req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	// server span continues caller's trace, its ids are logged and returned to caller
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	require.Equal(t, sc.Traceparent(), rec.Header().Get(tracing.TraceresponseHeader))
+	require.Contains(t, buf.String(), "trace_id="+sc.TraceID.String()+" span_id="+sc.SpanID.String())
+
+	require.NoError(t, tracer.Shutdown(context.Background()))
+	require.Contains(t, buf.String(), `"parent_span_id":"00f067aa0ba902b7","name":"GET /cache/:id","kind":"server"`)
+	require.Contains(t, buf.String(), `"http.response.status_code":500`)
+	require.Contains(t, buf.String(), `"status":"error","status_message":"Internal Server Error"`)
+}
@@ -0,0 +1,75 @@
+// Package singleflight provides suppression of duplicate concurrent calls.
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrPanicked is returned to waiting callers when function of the call panicked.
+var ErrPanicked = errors.New("singleflight: call panicked")
+
+// Group runs only one call per key at a time, duplicate callers wait for its result.
+// Zero value is ready to use.
+type Group[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*call[T]
+}
+
+type call[T any] struct {
+	done chan struct{}
+	dups int // dups counts callers waiting for the call
+	val  T
+	err  error
+}
+
+// Do runs fn unless call of the same key is in flight, otherwise waits for that call and returns its result.
+// shared reports whether result was returned by call of another caller.
+// Waiting is interrupted when ctx is done, the running call is not affected.
+func (g *Group[T]) Do(ctx context.Context, key string, fn func() (T, error)) (res T, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call[T])
+	}
+
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+
+		select {
+		case <-c.done:
+			return c.val, true, c.err
+		case <-ctx.Done():
+			return res, true, ctx.Err() //nolint:wrapcheck // context errors are returned as is
+		}
+	}
+
+	c := &call[T]{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	g.run(key, c, fn)
+
+	return c.val, false, c.err
+}
+
+// run calls fn and releases waiting callers even if fn panics.
+func (g *Group[T]) run(key string, c *call[T], fn func() (T, error)) {
+	finished := false
+
+	defer func() {
+		if !finished {
+			c.err = ErrPanicked
+		}
+
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+
+		close(c.done)
+	}()
+
+	c.val, c.err = fn()
+	finished = true
+}
@@ -0,0 +1,120 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDo(t *testing.T) {
+	var g Group[int]
+
+	res, shared, err := g.Do(context.Background(), "key", func() (int, error) { return 1, nil })
+	require.NoError(t, err)
+	require.False(t, shared)
+	require.Equal(t, 1, res)
+
+	want := errors.New("failed")
+	_, _, err = g.Do(context.Background(), "key", func() (int, error) { return 0, want })
+	require.ErrorIs(t, err, want)
+}
+
+func TestDoCoalesces(t *testing.T) {
+	var (
+		g     Group[int]
+		calls atomic.Int32
+		wg    sync.WaitGroup
+	)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	go func() {
+		_, _, _ = g.Do(context.Background(), "key", func() (int, error) {
+			calls.Add(1)
+			close(started)
+			<-release
+			return 42, nil
+		})
+	}()
+	<-started
+
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, shared, err := g.Do(context.Background(), "key", func() (int, error) {
+				calls.Add(1)
+				return 0, nil
+			})
+			require.NoError(t, err)
+			require.True(t, shared)
+			require.Equal(t, 42, res)
+		}()
+	}
+
+	// waiters must join the call before it finishes
+	for {
+		g.mu.Lock()
+		n := g.calls["key"].dups
+		g.mu.Unlock()
+		if n == 10 {
+			break
+		}
+		runtime.Gosched()
+	}
+
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestDoContextDone(t *testing.T) {
+	var g Group[int]
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		res, _, err := g.Do(context.Background(), "key", func() (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, res)
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, shared, err := g.Do(ctx, "key", func() (int, error) { return 2, nil })
+	require.ErrorIs(t, err, context.Canceled)
+	require.True(t, shared)
+
+	close(release)
+	<-done
+}
+
+func TestDoPanic(t *testing.T) {
+	var g Group[int]
+
+	require.Panics(t, func() {
+		_, _, _ = g.Do(context.Background(), "key", func() (int, error) { panic("boom") })
+	})
+
+	// key is released after panic
+	res, shared, err := g.Do(context.Background(), "key", func() (int, error) { return 1, nil })
+	require.NoError(t, err)
+	require.False(t, shared)
+	require.Equal(t, 1, res)
+}
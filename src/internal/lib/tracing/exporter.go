@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// WriterExporter writes every span as a json line, it is meant for local testing.
+type WriterExporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewWriterExporter is a constructor for WriterExporter, w isn't closed on shutdown.
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// NewFileExporter creates WriterExporter appending spans to file at path, file is created when doesn't exist.
+func NewFileExporter(path string) (*WriterExporter, error) {
+	const op = "tracing.NewFileExporter"
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &WriterExporter{w: f, closer: f}, nil
+}
+
+// spanLine is a json representation of span written by WriterExporter.
+type spanLine struct {
+	TraceID       string         `json:"trace_id"`
+	SpanID        string         `json:"span_id"`
+	ParentSpanID  string         `json:"parent_span_id,omitempty"`
+	Name          string         `json:"name"`
+	Kind          string         `json:"kind"`
+	Start         time.Time      `json:"start"`
+	DurationUs    int64          `json:"duration_us"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+	Status        string         `json:"status"`
+	StatusMessage string         `json:"status_message,omitempty"`
+}
+
+// Export writes spans.
+func (e *WriterExporter) Export(_ context.Context, spans []SpanData) error {
+	const op = "tracing.WriterExporter.Export"
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	enc := json.NewEncoder(e.w)
+	for i := range spans {
+		if err := enc.Encode(newSpanLine(&spans[i])); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// Shutdown closes file of exporter created by NewFileExporter.
+func (e *WriterExporter) Shutdown(_ context.Context) error {
+	const op = "tracing.WriterExporter.Shutdown"
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closer == nil {
+		return nil
+	}
+
+	if err := e.closer.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	e.closer = nil
+
+	return nil
+}
+
+func newSpanLine(s *SpanData) spanLine {
+	line := spanLine{
+		TraceID:       s.SpanContext.TraceID.String(),
+		SpanID:        s.SpanContext.SpanID.String(),
+		Name:          s.Name,
+		Kind:          s.Kind.String(),
+		Start:         s.Start,
+		DurationUs:    s.End.Sub(s.Start).Microseconds(),
+		Status:        s.Status.String(),
+		StatusMessage: s.StatusMessage,
+	}
+
+	if s.Parent.IsValid() {
+		line.ParentSpanID = s.Parent.String()
+	}
+
+	if len(s.Attributes) > 0 {
+		line.Attributes = make(map[string]any, len(s.Attributes))
+		for _, a := range s.Attributes {
+			line.Attributes[a.Key] = a.Value
+		}
+	}
+
+	return line
+}
@@ -0,0 +1,200 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errExportRejected = errors.New("export rejected by collector")
+
+const (
+	defaultOTLPTimeout = 10 * time.Second
+	scopeName          = "mortgage-calculator"
+)
+
+// OTLPOptions configure OTLPExporter, zero value of timeout means its default.
+type OTLPOptions struct {
+	Endpoint string            // Endpoint is url of traces receiver, e.g. http://localhost:4318/v1/traces.
+	Headers  map[string]string // Headers are added to every request, e.g. authorization.
+	Service  string            // Service is a value of service.name resource attribute.
+	Timeout  time.Duration     // Timeout limits single export request.
+}
+
+// OTLPExporter sends spans to OpenTelemetry collector using OTLP over HTTP with json encoding.
+type OTLPExporter struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+	service string
+}
+
+// NewOTLPExporter is a constructor for OTLPExporter.
+func NewOTLPExporter(opts OTLPOptions) *OTLPExporter {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultOTLPTimeout
+	}
+
+	return &OTLPExporter{
+		client:  &http.Client{Timeout: opts.Timeout},
+		url:     opts.Endpoint,
+		headers: opts.Headers,
+		service: opts.Service,
+	}
+}
+
+// Export sends spans in single request.
+func (e *OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	const op = "tracing.OTLPExporter.Export"
+
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	// body is drained, so connection is reused
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %w: %s %s", op, errExportRejected, resp.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+// Shutdown closes idle connections to collector.
+func (e *OTLPExporter) Shutdown(_ context.Context) error {
+	e.client.CloseIdleConnections()
+
+	return nil
+}
+
+// OTLP json representation of spans, ids are hex encoded and 64-bit integers are strings.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              Kind            `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+
+	otlpStatus struct {
+		Code    StatusCode `json:"code"`
+		Message string     `json:"message,omitempty"`
+	}
+
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+func (e *OTLPExporter) request(spans []SpanData) otlpRequest {
+	res := make([]otlpSpan, len(spans))
+	for i := range spans {
+		s := &spans[i]
+
+		res[i] = otlpSpan{
+			TraceID:           s.SpanContext.TraceID.String(),
+			SpanID:            s.SpanContext.SpanID.String(),
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(s.Attributes),
+			Status:            otlpStatus{Code: s.Status, Message: s.StatusMessage},
+		}
+		if s.Parent.IsValid() {
+			res[i].ParentSpanID = s.Parent.String()
+		}
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource:   otlpResource{Attributes: otlpAttributes([]Attribute{String("service.name", e.service)})},
+			ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: res}},
+		}},
+	}
+}
+
+func otlpAttributes(attrs []Attribute) []otlpAttribute {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	res := make([]otlpAttribute, len(attrs))
+	for i, a := range attrs {
+		res[i].Key = a.Key
+
+		switch v := a.Value.(type) {
+		case bool:
+			res[i].Value.BoolValue = &v
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			res[i].Value.IntValue = &s
+		case float64:
+			res[i].Value.DoubleValue = &v
+		default:
+			s := fmt.Sprint(v)
+			res[i].Value.StringValue = &s
+		}
+	}
+
+	return res
+}
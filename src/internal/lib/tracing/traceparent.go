@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Headers of W3C trace context.
+const (
+	TraceparentHeader   = "traceparent"   // TraceparentHeader carries span context of caller.
+	TraceresponseHeader = "traceresponse" // TraceresponseHeader returns span context of server to caller.
+)
+
+// ErrBadTraceparent represents error when traceparent header is malformed.
+var ErrBadTraceparent = errors.New("invalid traceparent")
+
+var errUppercaseHex = errors.New("uppercase hex digit")
+
+const (
+	traceparentLen = 55 // version-trace_id-parent_id-flags
+	flagSampled    = 0x01
+)
+
+// ParseTraceparent parses value of traceparent header.
+// Headers of future versions are accepted as long as they start with fields of version 00.
+func ParseTraceparent(s string) (SpanContext, error) {
+	if len(s) < traceparentLen || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return SpanContext{}, ErrBadTraceparent
+	}
+
+	version, err := decodeHex(s[0:2])
+	if err != nil || version[0] == 0xff {
+		return SpanContext{}, ErrBadTraceparent
+	}
+	if version[0] == 0 && len(s) != traceparentLen {
+		return SpanContext{}, ErrBadTraceparent
+	}
+	if version[0] > 0 && len(s) > traceparentLen && s[traceparentLen] != '-' {
+		return SpanContext{}, ErrBadTraceparent
+	}
+
+	var sc SpanContext
+
+	traceID, err := decodeHex(s[3:35])
+	if err != nil {
+		return SpanContext{}, fmt.Errorf("%w: trace id: %s", ErrBadTraceparent, err.Error())
+	}
+	copy(sc.TraceID[:], traceID)
+
+	spanID, err := decodeHex(s[36:52])
+	if err != nil {
+		return SpanContext{}, fmt.Errorf("%w: parent id: %s", ErrBadTraceparent, err.Error())
+	}
+	copy(sc.SpanID[:], spanID)
+
+	flags, err := decodeHex(s[53:55])
+	if err != nil {
+		return SpanContext{}, fmt.Errorf("%w: flags: %s", ErrBadTraceparent, err.Error())
+	}
+	sc.Sampled = flags[0]&flagSampled != 0
+
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("%w: zero id", ErrBadTraceparent)
+	}
+
+	return sc, nil
+}
+
+// Traceparent returns value of traceparent or traceresponse header passing sc to another service.
+func (sc SpanContext) Traceparent() string {
+	var flags byte
+	if sc.Sampled {
+		flags = flagSampled
+	}
+
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, flags)
+}
+
+// decodeHex decodes lowercase hex, uppercase digits are not allowed by trace context.
+func decodeHex(s string) ([]byte, error) {
+	for i := range len(s) {
+		if s[i] >= 'A' && s[i] <= 'F' {
+			return nil, errUppercaseHex
+		}
+	}
+
+	return hex.DecodeString(s) //nolint:wrapcheck // wrapped by caller
+}
@@ -0,0 +1,503 @@
+// Package tracing provides spans propagated by W3C trace context and exported in batches.
+// It implements subset of OpenTelemetry tracing used by application, without third-party libraries.
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultQueueSize = 2048
+	defaultBatchSize = 512
+	defaultInterval  = 5 * time.Second
+)
+
+// TraceID identifies trace, it is shared by all spans of one request across services.
+type TraceID [16]byte
+
+// IsValid reports whether id is not zero.
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// String returns lowercase hex representation of id.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID identifies span within trace.
+type SpanID [8]byte
+
+// IsValid reports whether id is not zero.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// String returns lowercase hex representation of id.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanContext identifies span across process boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool // Sampled reports whether span is recorded and exported.
+	Remote  bool // Remote reports whether span context is received from another service.
+}
+
+// IsValid reports whether both trace and span ids are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Kind is a role of span in trace, values match OTLP.
+type Kind int
+
+// Span kinds.
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+)
+
+// String returns name of kind.
+func (k Kind) String() string {
+	switch k {
+	case KindServer:
+		return "server"
+	default:
+		return "internal"
+	}
+}
+
+// StatusCode is an outcome of span, values match OTLP.
+type StatusCode int
+
+// Status codes.
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// String returns name of status code.
+func (c StatusCode) String() string {
+	switch c {
+	case StatusOK:
+		return "ok"
+	case StatusError:
+		return "error"
+	default:
+		return "unset"
+	}
+}
+
+// Attribute is a key and value describing span, value is one of string, bool, int64 or float64.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String returns string attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool returns bool attribute.
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns integer attribute.
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+// Float64 returns float attribute.
+func Float64(key string, value float64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// SpanData is a snapshot of ended span passed to exporter.
+type SpanData struct {
+	Name          string
+	Kind          Kind
+	SpanContext   SpanContext
+	Parent        SpanID // Parent is zero for root span.
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	Status        StatusCode
+	StatusMessage string
+}
+
+// Span is an operation of trace. Nil span is valid and does nothing, so callers don't check whether tracing is on.
+type Span struct {
+	tracer *Tracer
+	mu     sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+// SpanContext returns identity of span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+
+	return s.data.SpanContext
+}
+
+// SetAttributes adds attributes to span, attribute with existing key replaces previous value.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	for _, a := range attrs {
+		s.setAttribute(a)
+	}
+}
+
+func (s *Span) setAttribute(a Attribute) {
+	for i := range s.data.Attributes {
+		if s.data.Attributes[i].Key == a.Key {
+			s.data.Attributes[i] = a
+			return
+		}
+	}
+
+	s.data.Attributes = append(s.data.Attributes, a)
+}
+
+// SetStatus sets outcome of span, message is kept only for error status.
+func (s *Span) SetStatus(code StatusCode, msg string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	s.data.Status = code
+	s.data.StatusMessage = ""
+	if code == StatusError {
+		s.data.StatusMessage = msg
+	}
+}
+
+// RecordError marks span as failed with err, nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End completes span and queues it for export if it is sampled. Calls after the first one are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if data.SpanContext.Sampled {
+		s.tracer.enqueue(data)
+	}
+}
+
+// Exporter sends ended spans to tracing backend.
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// Options tune sampling and batching of spans, zero value of any option means its default.
+type Options struct {
+	// SampleRatio is a fraction of traces started by this service which are recorded, all traces are recorded by default.
+	// Traces started by caller follow its sampling decision.
+	SampleRatio float64
+	QueueSize   int           // QueueSize limits spans waiting for export, spans beyond it are dropped.
+	BatchSize   int           // BatchSize is a number of spans exported at once.
+	Interval    time.Duration // Interval limits time span waits for export.
+}
+
+// Tracer starts spans and exports sampled ones in background.
+// Tracer without exporter still assigns ids, so traces are propagated and correlated in logs.
+type Tracer struct {
+	log      *slog.Logger
+	exporter Exporter
+	ratio    float64
+
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan SpanData
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+// NewTracer is a constructor for Tracer, nil exporter disables export.
+func NewTracer(
+	log *slog.Logger,
+	exporter Exporter,
+	opts Options,
+) *Tracer {
+	if opts.SampleRatio <= 0 || opts.SampleRatio > 1 {
+		opts.SampleRatio = 1
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+
+	t := &Tracer{
+		log:      log,
+		exporter: exporter,
+		ratio:    opts.SampleRatio,
+		done:     make(chan struct{}),
+	}
+
+	if exporter == nil {
+		close(t.done)
+		return t
+	}
+
+	t.queue = make(chan SpanData, opts.QueueSize)
+	go t.run(opts.BatchSize, opts.Interval)
+
+	return t
+}
+
+// Start starts span as a child of span carried by ctx, span without parent starts new trace.
+// Returned context carries started span.
+func (t *Tracer) Start(ctx context.Context, name string, kind Kind, attrs ...Attribute) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.sample(sc.TraceID)
+	}
+
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:        name,
+			Kind:        kind,
+			SpanContext: sc,
+			Parent:      parent.SpanID,
+			Start:       time.Now(),
+		},
+	}
+	span.SetAttributes(attrs...)
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// Shutdown stops accepting spans, exports queued ones and shuts exporter down.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	const op = "tracing.Tracer.Shutdown"
+
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	if t.queue != nil {
+		close(t.queue)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+
+	if t.exporter == nil {
+		return nil
+	}
+
+	if err := t.exporter.Shutdown(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// sample decides whether new trace is recorded, decision depends only on trace id.
+func (t *Tracer) sample(id TraceID) bool {
+	if t.ratio >= 1 {
+		return true
+	}
+
+	return float64(binary.BigEndian.Uint64(id[8:])) < t.ratio*math.MaxUint64
+}
+
+// enqueue queues span for export without blocking, span is dropped when queue is full.
+func (t *Tracer) enqueue(data SpanData) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.closed || t.queue == nil {
+		return
+	}
+
+	select {
+	case t.queue <- data:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+// run exports spans when batch is full or interval has passed, remaining spans are exported when queue is closed.
+func (t *Tracer) run(batchSize int, interval time.Duration) {
+	defer close(t.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, batchSize)
+	for {
+		select {
+		case data, ok := <-t.queue:
+			if !ok {
+				t.export(batch)
+				return
+			}
+
+			batch = append(batch, data)
+			if len(batch) >= batchSize {
+				t.export(batch)
+				batch = make([]SpanData, 0, batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				t.export(batch)
+				batch = make([]SpanData, 0, batchSize)
+			}
+		}
+	}
+}
+
+func (t *Tracer) export(batch []SpanData) {
+	const op = "tracing.Tracer.export"
+	log := t.log.With(slog.String("op", op))
+
+	if dropped := t.dropped.Swap(0); dropped > 0 {
+		log.Warn("spans dropped, export queue is full", slog.Int64("dropped", dropped))
+	}
+
+	if len(batch) == 0 {
+		return
+	}
+
+	// exporter limits duration of request itself, so slow backend doesn't block shutdown forever
+	if err := t.exporter.Export(context.Background(), batch); err != nil {
+		log.Warn("failed to export spans", slog.Int("spans", len(batch)), slog.Any("error", err))
+	}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	}
+
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	}
+
+	return id
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// ContextWithSpan returns copy of ctx carrying span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// ContextWithRemoteSpanContext returns copy of ctx carrying span context received from caller,
+// span started with it becomes child of caller's span.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanFromContext returns span carried by ctx or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+
+	return span
+}
+
+// SpanContextFromContext returns context of span carried by ctx or remote span context if there is no span.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+
+	return sc
+}
+
+// Start starts internal span as a child of span carried by ctx using its tracer.
+// Nil span is returned when ctx carries no span, e.g. when tracing isn't set up in tests.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+
+	return parent.tracer.Start(ctx, name, KindInternal, attrs...)
+}
@@ -0,0 +1,281 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func setup(exporter Exporter, opts Options) *Tracer {
+	return NewTracer(slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})), exporter, opts)
+}
+
+// recorder keeps exported spans.
+type recorder struct {
+	mu    sync.Mutex
+	spans []SpanData
+	err   error
+}
+
+func (r *recorder) Export(_ context.Context, spans []SpanData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.spans = append(r.spans, spans...)
+	return r.err
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.spans)
+}
+
+func (r *recorder) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		sampled bool
+		valid   bool
+	}{
+		{name: "sampled", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sampled: true, valid: true},
+		{name: "not sampled", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", valid: true},
+		{name: "future version", header: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", sampled: true, valid: true},
+		{name: "empty", header: ""},
+		{name: "forbidden version", header: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "extra data of version 00", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{name: "uppercase", header: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{name: "zero trace id", header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "zero span id", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{name: "bad delimiter", header: "00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "not hex", header: "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := ParseTraceparent(tt.header)
+			if !tt.valid {
+				require.ErrorIs(t, err, ErrBadTraceparent)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+			require.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+			require.Equal(t, tt.sampled, sc.Sampled)
+		})
+	}
+}
+
+func TestSpanContext_Traceparent(t *testing.T) {
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	sc, err := ParseTraceparent(header)
+	require.NoError(t, err)
+	require.Equal(t, header, sc.Traceparent())
+}
+
+func TestTracer_Start(t *testing.T) {
+	rec := &recorder{}
+	tracer := setup(rec, Options{})
+
+	remote, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+
+	ctx := ContextWithRemoteSpanContext(context.Background(), remote)
+	ctx, server := tracer.Start(ctx, "POST /execute", KindServer, String("http.route", "/execute"))
+
+	_, child := Start(ctx, "CalcController.Calculate", String("program", "salary"))
+	child.SetAttributes(Bool("cache.hit", false), String("program", "military"))
+	child.RecordError(errors.New("calculation failed"))
+	child.End()
+	child.End() // ended span is exported once
+	server.End()
+
+	require.NoError(t, tracer.Shutdown(context.Background()))
+	require.Len(t, rec.spans, 2)
+
+	c, s := rec.spans[0], rec.spans[1]
+	require.Equal(t, remote.TraceID, s.SpanContext.TraceID)
+	require.Equal(t, remote.SpanID, s.Parent)
+	require.Equal(t, KindServer, s.Kind)
+
+	require.Equal(t, remote.TraceID, c.SpanContext.TraceID)
+	require.Equal(t, s.SpanContext.SpanID, c.Parent)
+	require.Equal(t, KindInternal, c.Kind)
+	require.Equal(t, []Attribute{String("program", "military"), Bool("cache.hit", false)}, c.Attributes)
+	require.Equal(t, StatusError, c.Status)
+	require.Equal(t, "calculation failed", c.StatusMessage)
+	require.False(t, c.End.Before(c.Start))
+}
+
+func TestTracer_Sampling(t *testing.T) {
+	rec := &recorder{}
+	tracer := setup(rec, Options{SampleRatio: 0.000001})
+
+	// caller's decision is followed
+	remote, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	require.NoError(t, err)
+
+	_, span := tracer.Start(ContextWithRemoteSpanContext(context.Background(), remote), "not sampled", KindServer)
+	require.False(t, span.SpanContext().Sampled)
+	span.End()
+
+	// new traces are sampled by ratio
+	sampled := 0
+	for range 100 {
+		_, span := tracer.Start(context.Background(), "root", KindServer)
+		if span.SpanContext().Sampled {
+			sampled++
+		}
+		span.End()
+	}
+
+	require.NoError(t, tracer.Shutdown(context.Background()))
+	require.Len(t, rec.spans, sampled)
+	require.Less(t, sampled, 100)
+}
+
+func TestTracer_Batch(t *testing.T) {
+	rec := &recorder{err: errors.New("collector is unavailable")}
+	tracer := setup(rec, Options{BatchSize: 2, Interval: time.Hour})
+
+	for range 3 {
+		_, span := tracer.Start(context.Background(), "span", KindInternal)
+		span.End()
+	}
+
+	// full batch is exported without waiting for interval, failed export doesn't stop tracer
+	require.Eventually(t, func() bool { return rec.count() == 2 }, time.Second, time.Millisecond)
+
+	require.NoError(t, tracer.Shutdown(context.Background()))
+	require.Len(t, rec.spans, 3)
+
+	// spans ended after shutdown are discarded
+	_, span := tracer.Start(context.Background(), "late", KindInternal)
+	span.End()
+	require.Len(t, rec.spans, 3)
+}
+
+func TestStart_WithoutTracer(t *testing.T) {
+	ctx, span := Start(context.Background(), "CalcRepository.Get")
+
+	require.Nil(t, span)
+	require.False(t, SpanContextFromContext(ctx).IsValid())
+
+	// nil span does nothing
+	span.SetAttributes(Bool("cache.hit", true))
+	span.RecordError(errors.New("failed"))
+	span.End()
+}
+
+func TestWriterExporter(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := setup(NewWriterExporter(&buf), Options{})
+
+	ctx, parent := tracer.Start(context.Background(), "parent", KindServer)
+	_, child := Start(ctx, "child", Int("months", 12))
+	child.End()
+	parent.End()
+
+	require.NoError(t, tracer.Shutdown(context.Background()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var line spanLine
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	require.Equal(t, "child", line.Name)
+	require.Equal(t, "internal", line.Kind)
+	require.Equal(t, "unset", line.Status)
+	require.Equal(t, parent.SpanContext().SpanID.String(), line.ParentSpanID)
+	require.Equal(t, parent.SpanContext().TraceID.String(), line.TraceID)
+	require.Equal(t, map[string]any{"months": float64(12)}, line.Attributes)
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		body   map[string]any
+		header http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	exporter := NewOTLPExporter(OTLPOptions{
+		Endpoint: srv.URL + "/v1/traces",
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		Service:  "mortgage-calculator",
+	})
+
+	sc := SpanContext{TraceID: TraceID{1}, SpanID: SpanID{2}, Sampled: true}
+	start := time.Unix(1700000000, 0)
+	err := exporter.Export(context.Background(), []SpanData{{
+		Name:          "CalcRepository.Get",
+		Kind:          KindInternal,
+		SpanContext:   sc,
+		Parent:        SpanID{3},
+		Start:         start,
+		End:           start.Add(time.Millisecond),
+		Attributes:    []Attribute{Bool("cache.hit", true), Int("months", 12), String("program", "salary")},
+		Status:        StatusError,
+		StatusMessage: "failed",
+	}})
+	require.NoError(t, err)
+
+	require.Equal(t, "application/json", header.Get("Content-Type"))
+	require.Equal(t, "Bearer token", header.Get("Authorization"))
+
+	expected := `{"resourceSpans":[{
+		"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"mortgage-calculator"}}]},
+		"scopeSpans":[{"scope":{"name":"mortgage-calculator"},"spans":[{
+			"traceId":"01000000000000000000000000000000",
+			"spanId":"0200000000000000",
+			"parentSpanId":"0300000000000000",
+			"name":"CalcRepository.Get",
+			"kind":1,
+			"startTimeUnixNano":"1700000000000000000",
+			"endTimeUnixNano":"1700000000001000000",
+			"attributes":[
+				{"key":"cache.hit","value":{"boolValue":true}},
+				{"key":"months","value":{"intValue":"12"}},
+				{"key":"program","value":{"stringValue":"salary"}}
+			],
+			"status":{"code":2,"message":"failed"}
+		}]}]
+	}]}`
+	actual, err := json.Marshal(body)
+	require.NoError(t, err)
+	require.JSONEq(t, expected, string(actual))
+}
+
+func TestOTLPExporter_Rejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	exporter := NewOTLPExporter(OTLPOptions{Endpoint: srv.URL})
+
+	err := exporter.Export(context.Background(), []SpanData{{Name: "span"}})
+	require.ErrorIs(t, err, errExportRejected)
+	require.ErrorContains(t, err, "quota exceeded")
+}
@@ -0,0 +1,23 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	fallback := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	require.Same(t, fallback, FromContext(context.Background(), fallback))
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{})).With(slog.String("request_id", "abc"))
+
+	ctx := WithContext(context.Background(), log)
+	FromContext(ctx, fallback).Info("calculated")
+
+	require.Contains(t, buf.String(), "request_id=abc")
+}
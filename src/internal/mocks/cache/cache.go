@@ -25,10 +25,28 @@ func (m *MockCache) Set(ctx context.Context, key string, value []byte) error {
 }
 
 // Clear checks whether entries are expired and deletes them if true.
-func (m *MockCache) Clear(_ context.Context) {}
+func (m *MockCache) Clear(_ context.Context) int { return 0 }
 
 // List returns all active cache entries.
 func (m *MockCache) List(ctx context.Context) ([]*cachepkg.Entry, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]*cachepkg.Entry), args.Error(1) //nolint:wrapcheck,errcheck // already returns wrapped errors
 }
+
+// GetByID returns entry by id.
+func (m *MockCache) GetByID(ctx context.Context, id int64) (*cachepkg.Entry, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*cachepkg.Entry), args.Error(1) //nolint:wrapcheck,errcheck // already returns wrapped errors
+}
+
+// Delete deletes entry by id.
+func (m *MockCache) Delete(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0) //nolint:wrapcheck // already returns wrapped errors
+}
+
+// Purge deletes all entries.
+func (m *MockCache) Purge(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1) //nolint:wrapcheck // already returns wrapped errors
+}
@@ -25,8 +25,58 @@ func (m *MockCacheGetSaver) Set(ctx context.Context, in *requests.CalculateReque
 	return args.Error(0) //nolint:wrapcheck // already returns wrapped errors
 }
 
+// GetOrCompute mocks retrieving cached calc result through mocked Get, on miss result is computed and saved through mocked Set.
+func (m *MockCacheGetSaver) GetOrCompute(
+	ctx context.Context,
+	in *requests.CalculateRequest,
+	compute func(ctx context.Context) (*dto.CalcAggregates, error),
+) (*dto.CalcAggregates, error) {
+	if res, err := m.Get(ctx, in); err == nil {
+		return res, nil
+	}
+
+	res, err := compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = m.Set(ctx, in, res)
+
+	return res, nil
+}
+
 // List mocks listing calc results.
 func (m *MockCacheGetSaver) List(ctx context.Context) ([]*dto.CacheEntry, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]*dto.CacheEntry), args.Error(1) //nolint:wrapcheck,errcheck // already returns wrapped errors
 }
+
+// Stats mocks retrieving cache statistics.
+func (m *MockCacheGetSaver) Stats(ctx context.Context) (*dto.CacheStats, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*dto.CacheStats), args.Error(1) //nolint:wrapcheck,errcheck // already returns wrapped errors
+}
+
+// GetByID mocks retrieving calc result by id.
+func (m *MockCacheGetSaver) GetByID(ctx context.Context, id int64) (*dto.CacheEntry, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*dto.CacheEntry), args.Error(1) //nolint:wrapcheck,errcheck // already returns wrapped errors
+}
+
+// Delete mocks deleting calc result by id.
+func (m *MockCacheGetSaver) Delete(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0) //nolint:wrapcheck // already returns wrapped errors
+}
+
+// Purge mocks deleting all calc results.
+func (m *MockCacheGetSaver) Purge(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1) //nolint:wrapcheck // already returns wrapped errors
+}
+
+// Clear mocks deleting expired calc results.
+func (m *MockCacheGetSaver) Clear(ctx context.Context) int {
+	args := m.Called(ctx)
+	return args.Int(0)
+}
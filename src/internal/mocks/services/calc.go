@@ -5,6 +5,7 @@ import (
 	"context"
 	"github.com/stretchr/testify/mock"
 	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/money"
 )
 
 // MockCalculator mocks service layer for calculations.
@@ -17,3 +18,96 @@ func (m *MockCalculator) Calculate(ctx context.Context, params dto.CalcParams, p
 	args := m.Called(ctx, params, program)
 	return args.Get(0).(*dto.CalcAggregates), args.Error(1) //nolint:wrapcheck,errcheck // already returns wrapped errors
 }
+
+// Schedule mocks schedule calculations.
+func (m *MockCalculator) Schedule(ctx context.Context, params dto.CalcParams, program dto.CalcProgram) (*dto.CalcSchedule, error) {
+	args := m.Called(ctx, params, program)
+	return args.Get(0).(*dto.CalcSchedule), args.Error(1) //nolint:wrapcheck,errcheck // already returns wrapped errors
+}
+
+// Programs mocks listing programs.
+func (m *MockCalculator) Programs() []dto.Program {
+	args := m.Called()
+	return args.Get(0).([]dto.Program) //nolint:errcheck // mock always returns programs
+}
+
+// Affordability mocks debt-to-income check.
+func (m *MockCalculator) Affordability(ctx context.Context, borrower dto.Borrower, aggregates *dto.CalcAggregates, program dto.CalcProgram) (*dto.Affordability, error) {
+	args := m.Called(ctx, borrower, aggregates, program)
+	return args.Get(0).(*dto.Affordability), args.Error(1) //nolint:wrapcheck,errcheck // already returns wrapped errors
+}
+
+// MockSolver mocks service layer for reverse calculations.
+type MockSolver struct {
+	mock.Mock
+}
+
+// MaxLoan mocks maximum loan search.
+func (m *MockSolver) MaxLoan(ctx context.Context, payment money.Amount, params dto.CalcParams, program dto.CalcProgram) (*dto.Solution, error) {
+	args := m.Called(ctx, payment, params, program)
+	return args.Get(0).(*dto.Solution), args.Error(1) //nolint:wrapcheck,errcheck // already returns wrapped errors
+}
+
+// MinTerm mocks minimal term search.
+func (m *MockSolver) MinTerm(ctx context.Context, payment money.Amount, params dto.CalcParams, program dto.CalcProgram) (*dto.Solution, error) {
+	args := m.Called(ctx, payment, params, program)
+	return args.Get(0).(*dto.Solution), args.Error(1) //nolint:wrapcheck,errcheck // already returns wrapped errors
+}
+
+// MockCacheProvider mocks service layer for cache analysis.
+type MockCacheProvider struct {
+	mock.Mock
+}
+
+// List mocks listing cache entries.
+func (m *MockCacheProvider) List(ctx context.Context, query dto.CacheQuery) (*dto.CacheList, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(*dto.CacheList), args.Error(1) //nolint:wrapcheck,errcheck // already returns wrapped errors
+}
+
+// Stats mocks retrieving cache statistics.
+func (m *MockCacheProvider) Stats(ctx context.Context, top int) (*dto.CacheStats, error) {
+	args := m.Called(ctx, top)
+	return args.Get(0).(*dto.CacheStats), args.Error(1) //nolint:wrapcheck,errcheck // already returns wrapped errors
+}
+
+// Get mocks retrieving cache entry.
+func (m *MockCacheProvider) Get(ctx context.Context, id int64) (*dto.CacheEntry, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*dto.CacheEntry), args.Error(1) //nolint:wrapcheck,errcheck // already returns wrapped errors
+}
+
+// Delete mocks deleting cache entry.
+func (m *MockCacheProvider) Delete(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0) //nolint:wrapcheck // already returns wrapped errors
+}
+
+// Purge mocks deleting all cache entries.
+func (m *MockCacheProvider) Purge(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1) //nolint:wrapcheck // already returns wrapped errors
+}
+
+// ClearExpired mocks deleting expired cache entries.
+func (m *MockCacheProvider) ClearExpired(ctx context.Context) int {
+	args := m.Called(ctx)
+	return args.Int(0)
+}
+
+// MockHealthProvider mocks service layer for health checks.
+type MockHealthProvider struct {
+	mock.Mock
+}
+
+// Live mocks liveness check.
+func (m *MockHealthProvider) Live(ctx context.Context) *dto.Health {
+	args := m.Called(ctx)
+	return args.Get(0).(*dto.Health) //nolint:errcheck // mock returns configured value
+}
+
+// Ready mocks readiness check.
+func (m *MockHealthProvider) Ready(ctx context.Context) *dto.Health {
+	args := m.Called(ctx)
+	return args.Get(0).(*dto.Health) //nolint:errcheck // mock returns configured value
+}
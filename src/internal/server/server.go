@@ -6,7 +6,9 @@ import (
 	"log/slog"
 	"mortgage-calculator/src/internal/controllers"
 	envpkg "mortgage-calculator/src/internal/lib/env"
+	"mortgage-calculator/src/internal/lib/metrics"
 	"mortgage-calculator/src/internal/lib/server/middleware"
+	"mortgage-calculator/src/internal/lib/tracing"
 )
 
 // NewRouter sets router mode based on env, registers middleware, defines handlers and options and creates new gin router.
@@ -14,7 +16,11 @@ func NewRouter(
 	log *slog.Logger,
 	env string,
 	calcCon *controllers.CalcController,
+	solverCon *controllers.SolverController,
 	cacheCon *controllers.CacheController,
+	healthCon *controllers.HealthController,
+	reg *metrics.Registry,
+	tracer *tracing.Tracer,
 ) *gin.Engine {
 	var mode string
 	switch env {
@@ -31,11 +37,24 @@ func NewRouter(
 	r.RedirectTrailingSlash = true
 	r.RedirectFixedPath = true
 
+	r.Use(middleware.Tracing(tracer))
 	r.Use(middleware.Logger(log))
+	r.Use(middleware.Metrics(reg))
 	r.Use(gin.Recovery())
 
+	r.GET("metrics", gin.WrapH(reg.Handler()))
+	r.GET("healthz", healthCon.Live)
+	r.GET("readyz", healthCon.Ready)
 	r.POST("execute", calcCon.Calculate)
+	r.POST("schedule", calcCon.Schedule)
+	r.POST("compare", calcCon.Compare)
+	r.POST("solve", solverCon.Solve)
 	r.GET("cache", cacheCon.List)
+	r.DELETE("cache", cacheCon.Purge)
+	r.GET("cache/stats", cacheCon.Stats)
+	r.POST("cache/clear-expired", cacheCon.ClearExpired)
+	r.GET("cache/:id", cacheCon.Get)
+	r.DELETE("cache/:id", cacheCon.Delete)
 
 	return r
 }
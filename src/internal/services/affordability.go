@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/money"
+	"mortgage-calculator/src/internal/logger"
+)
+
+// DefaultMaxDebtToIncome is applied to programs without configured debt-to-income threshold.
+const DefaultMaxDebtToIncome = 0.5
+
+// Affordability compares the largest payment of calculation result with borrower's income
+// and flags the result when debt-to-income ratio exceeds program threshold.
+func (s *CalculatorService) Affordability(
+	ctx context.Context,
+	borrower dto.Borrower,
+	aggregates *dto.CalcAggregates,
+	program dto.CalcProgram,
+) (*dto.Affordability, error) {
+	const op = "calculatorService.Affordability"
+	log := logger.FromContext(ctx, s.log).With(slog.String("op", op))
+
+	p, err := s.program(log, program)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	threshold := p.MaxDebtToIncome
+	if threshold == 0 {
+		threshold = DefaultMaxDebtToIncome
+	}
+
+	res := affordability(borrower, aggregates.MaxPayment, threshold)
+
+	if !res.Affordable {
+		log.Info(
+			"debt-to-income threshold exceeded",
+			slog.String("program", p.ID),
+			slog.Float64("debt_to_income", res.DebtToIncome),
+			slog.Float64("max_debt_to_income", threshold),
+		)
+	}
+
+	return res, nil
+}
+
+func affordability(borrower dto.Borrower, payment money.Amount, threshold float64) *dto.Affordability {
+	income := float64(borrower.MonthlyIncome)
+	dti := float64(payment+borrower.Obligations) / income
+
+	// payments are integer, so the limit is rounded down to stay within threshold,
+	// tolerance prevents products like 100 * 0.57 from dropping a whole unit
+	maxPayment := money.Amount(math.Floor(income*threshold+1e-6)) - borrower.Obligations
+
+	return &dto.Affordability{
+		MonthlyIncome:   borrower.MonthlyIncome,
+		Obligations:     borrower.Obligations,
+		Payment:         payment,
+		DebtToIncome:    math.Round(dti*10000) / 10000,
+		MaxDebtToIncome: threshold,
+		MaxPayment:      max(maxPayment, 0),
+		Affordable:      payment <= maxPayment,
+	}
+}
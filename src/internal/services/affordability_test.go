@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/money"
+	"testing"
+)
+
+func TestCalculatorService_Affordability(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, []dto.Program{
+		{ID: "base", Rate: 0.1},
+		{ID: "strict", Rate: 0.1, MaxDebtToIncome: 0.3},
+	}, DefaultCurrency, DefaultRounding, testClock)
+
+	aggregates := &dto.CalcAggregates{MonthlyPayment: 33458, MaxPayment: 33458}
+
+	res, err := service.Affordability(ctx, dto.Borrower{MonthlyIncome: 100000, Obligations: 10000}, aggregates, dto.CalcProgram{ID: "base"})
+	require.NoError(t, err)
+	require.Equal(t, &dto.Affordability{
+		MonthlyIncome:   100000,
+		Obligations:     10000,
+		Payment:         33458,
+		DebtToIncome:    0.4346,
+		MaxDebtToIncome: DefaultMaxDebtToIncome,
+		MaxPayment:      40000,
+		Affordable:      true,
+	}, res)
+
+	res, err = service.Affordability(ctx, dto.Borrower{MonthlyIncome: 100000, Obligations: 10000}, aggregates, dto.CalcProgram{ID: "strict"})
+	require.NoError(t, err)
+	require.False(t, res.Affordable)
+	require.Equal(t, 0.3, res.MaxDebtToIncome)
+	require.Equal(t, money.Amount(20000), res.MaxPayment)
+
+	res, err = service.Affordability(ctx, dto.Borrower{MonthlyIncome: 100000}, aggregates, dto.CalcProgram{ID: "family"})
+	require.ErrorIs(t, err, ErrUnknownProgram)
+	require.Empty(t, res)
+}
+
+func TestAffordability(t *testing.T) {
+	cases := []struct {
+		borrower   dto.Borrower
+		payment    money.Amount
+		threshold  float64
+		maxPayment money.Amount
+		affordable bool
+	}{
+		// payment exactly at threshold is affordable
+		{dto.Borrower{MonthlyIncome: 100}, 57, 0.57, 57, true},
+		{dto.Borrower{MonthlyIncome: 100}, 58, 0.57, 57, false},
+		{dto.Borrower{MonthlyIncome: 100, Obligations: 60}, 1, 0.5, 0, false},
+		{dto.Borrower{MonthlyIncome: 3}, 1, 0.5, 1, true},
+	}
+
+	for _, tt := range cases {
+		res := affordability(tt.borrower, tt.payment, tt.threshold)
+		require.Equal(t, tt.maxPayment, res.MaxPayment)
+		require.Equal(t, tt.affordable, res.Affordable)
+	}
+}
@@ -2,13 +2,32 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	cachepkg "mortgage-calculator/src/internal/cache"
 	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/logger"
+	"sort"
 )
 
+// ErrEntryNotFound represents error when cache entry with given id doesn't exist or has expired.
+var ErrEntryNotFound = errors.New("cache entry not found")
+
+// DefaultCacheLimit is a page size of cache entries list by default.
+const DefaultCacheLimit = 100
+
+// DefaultTopRequests is a number of the most requested params returned by Stats by default.
+const DefaultTopRequests = 10
+
 type cache interface {
 	List(ctx context.Context) ([]*dto.CacheEntry, error)
+	Stats(ctx context.Context) (*dto.CacheStats, error)
+	GetByID(ctx context.Context, id int64) (*dto.CacheEntry, error)
+	Delete(ctx context.Context, id int64) error
+	Purge(ctx context.Context) (int, error)
+	Clear(ctx context.Context) int
 }
 
 // CacheService provides api for cache entries analysis.
@@ -28,12 +47,13 @@ func NewCacheService(
 	}
 }
 
-// List lists all active cache entries.
+// List returns page of active cache entries matching query filters in requested order.
 func (s *CacheService) List(
 	ctx context.Context,
-) ([]*dto.CacheEntry, error) {
+	query dto.CacheQuery,
+) (*dto.CacheList, error) {
 	const op = "cacheService.List"
-	log := s.log.With(slog.String("op", op))
+	log := logger.FromContext(ctx, s.log).With(slog.String("op", op))
 
 	log.Info("retrieving cache entries")
 
@@ -44,7 +64,186 @@ func (s *CacheService) List(
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	log.Info("cache entries retrieved")
+	log.Info("cache entries retrieved, filtering", slog.Int("count", len(entries)))
+
+	filtered := make([]*dto.CacheEntry, 0, len(entries))
+	for _, e := range entries {
+		if matches(e, query) {
+			filtered = append(filtered, e)
+		}
+	}
+
+	sortEntries(filtered, query.Sort, query.Order)
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultCacheLimit
+	}
+	start := min(query.Offset, len(filtered))
+	end := min(start+limit, len(filtered))
+
+	return &dto.CacheList{
+		Total:   len(filtered),
+		Limit:   limit,
+		Offset:  query.Offset,
+		Entries: filtered[start:end],
+	}, nil
+}
+
+// Stats returns cache usage statistics with hit ratio and top most requested params.
+// Non-positive top means DefaultTopRequests.
+func (s *CacheService) Stats(
+	ctx context.Context,
+	top int,
+) (*dto.CacheStats, error) {
+	const op = "cacheService.Stats"
+	log := logger.FromContext(ctx, s.log).With(slog.String("op", op))
+
+	log.Info("retrieving cache statistics")
+
+	stats, err := s.cache.Stats(ctx)
+	if err != nil {
+		log.Error("failed to retrieve cache statistics")
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if top <= 0 {
+		top = DefaultTopRequests
+	}
+	stats.TopRequests = stats.TopRequests[:min(top, len(stats.TopRequests))]
+
+	if requests := stats.Hits + stats.Misses; requests > 0 {
+		stats.HitRatio = math.Round(float64(stats.Hits)/float64(requests)*10000) / 10000
+	}
+
+	log.Info("cache statistics retrieved")
+
+	return stats, nil
+}
+
+// Get returns cache entry by id.
+func (s *CacheService) Get(
+	ctx context.Context,
+	id int64,
+) (*dto.CacheEntry, error) {
+	const op = "cacheService.Get"
+	log := logger.FromContext(ctx, s.log).With(slog.String("op", op), slog.Int64("id", id))
+
+	log.Info("retrieving cache entry")
+
+	entry, err := s.cache.GetByID(ctx, id)
+	if errors.Is(err, cachepkg.ErrKeyNotExists) {
+		return nil, fmt.Errorf("%s: %w", op, ErrEntryNotFound)
+	}
+	if err != nil {
+		log.Error("failed to retrieve cache entry")
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entry, nil
+}
+
+// Delete deletes cache entry by id.
+func (s *CacheService) Delete(
+	ctx context.Context,
+	id int64,
+) error {
+	const op = "cacheService.Delete"
+	log := logger.FromContext(ctx, s.log).With(slog.String("op", op), slog.Int64("id", id))
+
+	log.Info("deleting cache entry")
+
+	err := s.cache.Delete(ctx, id)
+	if errors.Is(err, cachepkg.ErrKeyNotExists) {
+		return fmt.Errorf("%s: %w", op, ErrEntryNotFound)
+	}
+	if err != nil {
+		log.Error("failed to delete cache entry")
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("cache entry deleted")
+
+	return nil
+}
+
+// Purge deletes all cache entries and returns their number.
+func (s *CacheService) Purge(
+	ctx context.Context,
+) (int, error) {
+	const op = "cacheService.Purge"
+	log := logger.FromContext(ctx, s.log).With(slog.String("op", op))
+
+	log.Info("purging cache")
+
+	res, err := s.cache.Purge(ctx)
+	if err != nil {
+		log.Error("failed to purge cache")
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("cache purged")
+
+	return res, nil
+}
+
+// ClearExpired deletes expired cache entries and returns their number.
+func (s *CacheService) ClearExpired(
+	ctx context.Context,
+) int {
+	const op = "cacheService.ClearExpired"
+	log := logger.FromContext(ctx, s.log).With(slog.String("op", op))
+
+	log.Info("clearing expired cache entries")
+
+	return s.cache.Clear(ctx)
+}
+
+// matches reports whether cache entry satisfies query filters.
+func matches(e *dto.CacheEntry, q dto.CacheQuery) bool {
+	switch {
+	case q.Program != "" && e.Program.Key() != q.Program:
+		return false
+	case q.MinMonths > 0 && e.Params.Months < q.MinMonths:
+		return false
+	case q.MaxMonths > 0 && e.Params.Months > q.MaxMonths:
+		return false
+	case q.MinObjectCost > 0 && e.Params.ObjectCost < q.MinObjectCost:
+		return false
+	case q.MaxObjectCost > 0 && e.Params.ObjectCost > q.MaxObjectCost:
+		return false
+	default:
+		return true
+	}
+}
+
+// sortEntries sorts cache entries by field, entries with equal values are ordered by id.
+func sortEntries(entries []*dto.CacheEntry, field, order string) {
+	less := func(a, b *dto.CacheEntry) bool {
+		switch field {
+		case dto.CacheSortLoanSum:
+			if a.Aggregates.LoanSum != b.Aggregates.LoanSum {
+				return a.Aggregates.LoanSum < b.Aggregates.LoanSum
+			}
+		case dto.CacheSortCreatedAt:
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+		}
+
+		// ids are assigned in order of creation, so they order entries created at the same second
+		return a.ID < b.ID
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if order == dto.OrderDesc {
+			return less(entries[j], entries[i])
+		}
 
-	return entries, nil
+		return less(entries[i], entries[j])
+	})
 }
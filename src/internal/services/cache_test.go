@@ -7,9 +7,12 @@ import (
 	"github.com/stretchr/testify/require"
 	"io"
 	"log/slog"
+	cachepkg "mortgage-calculator/src/internal/cache"
 	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/money"
 	reposmock "mortgage-calculator/src/internal/mocks/repos"
 	"testing"
+	"time"
 )
 
 func setup() (*CacheService, *reposmock.MockCacheGetSaver) {
@@ -36,10 +39,10 @@ func TestCacheService_List(t *testing.T) {
 
 	c.On("List", mock.Anything).Return(entries, nil)
 
-	res, err := service.List(context.Background())
+	res, err := service.List(context.Background(), dto.CacheQuery{})
 	require.NoError(t, err)
 	require.NotEmpty(t, res)
-	require.Equal(t, entries, res)
+	require.Equal(t, &dto.CacheList{Total: 1, Limit: DefaultCacheLimit, Entries: entries}, res)
 }
 
 func TestCacheService_List_Error(t *testing.T) {
@@ -49,8 +52,180 @@ func TestCacheService_List_Error(t *testing.T) {
 
 	c.On("List", mock.Anything).Return(entries, errors.New("failed to retrieve cache entries"))
 
-	res, err := service.List(context.Background())
+	res, err := service.List(context.Background(), dto.CacheQuery{})
 	require.Error(t, err)
 	require.Empty(t, res)
 	require.Contains(t, err.Error(), "failed to retrieve cache entries")
 }
+
+func TestCacheService_List_Query(t *testing.T) {
+	service, c := setup()
+
+	entry := func(id int64, program string, months int, objectCost, loanSum money.Amount) *dto.CacheEntry {
+		return &dto.CacheEntry{
+			ID:         id,
+			Params:     &dto.CalcParams{ObjectCost: objectCost, Months: months},
+			Program:    &dto.CalcProgram{ID: program},
+			Aggregates: &dto.CalcAggregates{LoanSum: loanSum},
+		}
+	}
+
+	entries := []*dto.CacheEntry{
+		entry(3, "salary", 240, 500000000, 400000000),
+		entry(1, "salary", 120, 800000000, 600000000),
+		entry(2, "base", 240, 600000000, 400000000),
+		entry(4, "salary", 360, 300000000, 200000000),
+		entry(5, "salary", 240, 900000000, 700000000),
+	}
+	c.On("List", mock.Anything).Return(entries, nil)
+
+	ids := func(list *dto.CacheList) []int64 {
+		res := make([]int64, len(list.Entries))
+		for i, e := range list.Entries {
+			res[i] = e.ID
+		}
+		return res
+	}
+
+	cases := []struct {
+		query dto.CacheQuery
+		total int
+		ids   []int64
+	}{
+		{query: dto.CacheQuery{}, total: 5, ids: []int64{1, 2, 3, 4, 5}},
+		{query: dto.CacheQuery{Order: dto.OrderDesc}, total: 5, ids: []int64{5, 4, 3, 2, 1}},
+		{query: dto.CacheQuery{Sort: dto.CacheSortCreatedAt, Order: dto.OrderDesc, Limit: 2}, total: 5, ids: []int64{5, 4}},
+		{query: dto.CacheQuery{Sort: dto.CacheSortLoanSum}, total: 5, ids: []int64{4, 2, 3, 1, 5}},
+		{query: dto.CacheQuery{Sort: dto.CacheSortLoanSum, Order: dto.OrderDesc}, total: 5, ids: []int64{5, 1, 3, 2, 4}},
+		{query: dto.CacheQuery{Limit: 2, Offset: 2}, total: 5, ids: []int64{3, 4}},
+		{query: dto.CacheQuery{Offset: 10}, total: 5, ids: []int64{}},
+		{query: dto.CacheQuery{Program: "salary"}, total: 4, ids: []int64{1, 3, 4, 5}},
+		{query: dto.CacheQuery{MinMonths: 200, MaxMonths: 300}, total: 3, ids: []int64{2, 3, 5}},
+		{query: dto.CacheQuery{MinObjectCost: 500000000, MaxObjectCost: 800000000}, total: 3, ids: []int64{1, 2, 3}},
+		{query: dto.CacheQuery{Program: "salary", MinMonths: 240, Limit: 1, Offset: 1}, total: 3, ids: []int64{4}},
+	}
+
+	for _, tc := range cases {
+		res, err := service.List(context.Background(), tc.query)
+		require.NoError(t, err)
+		require.Equal(t, tc.total, res.Total, tc.query)
+		require.Equal(t, tc.ids, ids(res), tc.query)
+	}
+}
+
+func TestCacheService_List_SortCreatedAt(t *testing.T) {
+	service, c := setup()
+
+	created := time.Date(2024, 6, 18, 0, 0, 0, 0, time.UTC)
+	later := created.Add(time.Minute)
+
+	entries := []*dto.CacheEntry{
+		{ID: 1, CreatedAt: later, Aggregates: &dto.CalcAggregates{}},
+		{ID: 2, CreatedAt: created.Add(-time.Minute), Aggregates: &dto.CalcAggregates{}},
+		{ID: 3, CreatedAt: created, Aggregates: &dto.CalcAggregates{}},
+		{ID: 4, CreatedAt: created, Aggregates: &dto.CalcAggregates{}},
+	}
+	c.On("List", mock.Anything).Return(entries, nil)
+
+	res, err := service.List(context.Background(), dto.CacheQuery{Sort: dto.CacheSortCreatedAt})
+	require.NoError(t, err)
+	require.Equal(t, []int64{2, 3, 4, 1}, entryIDs(res))
+
+	res, err = service.List(context.Background(), dto.CacheQuery{Sort: dto.CacheSortCreatedAt, Order: dto.OrderDesc})
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 4, 3, 2}, entryIDs(res))
+}
+
+func entryIDs(list *dto.CacheList) []int64 {
+	res := make([]int64, len(list.Entries))
+	for i, e := range list.Entries {
+		res[i] = e.ID
+	}
+	return res
+}
+
+func TestCacheService_Stats(t *testing.T) {
+	service, c := setup()
+
+	top := make([]*dto.RequestCount, 15)
+	for i := range top {
+		top[i] = &dto.RequestCount{Count: int64(15 - i)}
+	}
+
+	c.On("Stats", mock.Anything).Return(&dto.CacheStats{Hits: 2, Misses: 1, TopRequests: top}, nil)
+
+	res, err := service.Stats(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, 0.6667, res.HitRatio)
+	require.Equal(t, top[:DefaultTopRequests], res.TopRequests)
+}
+
+func TestCacheService_Stats_Top(t *testing.T) {
+	service, c := setup()
+
+	top := []*dto.RequestCount{{Count: 2}, {Count: 1}}
+	c.On("Stats", mock.Anything).Return(&dto.CacheStats{TopRequests: top}, nil)
+
+	res, err := service.Stats(context.Background(), 1)
+	require.NoError(t, err)
+	require.Zero(t, res.HitRatio)
+	require.Equal(t, top[:1], res.TopRequests)
+}
+
+func TestCacheService_Stats_Error(t *testing.T) {
+	service, c := setup()
+
+	c.On("Stats", mock.Anything).Return((*dto.CacheStats)(nil), errors.New("failed to retrieve cache items"))
+
+	res, err := service.Stats(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, res)
+}
+
+func TestCacheService_Get(t *testing.T) {
+	service, c := setup()
+
+	entry := &dto.CacheEntry{ID: 1}
+	c.On("GetByID", mock.Anything, int64(1)).Return(entry, nil)
+	c.On("GetByID", mock.Anything, int64(2)).Return((*dto.CacheEntry)(nil), cachepkg.ErrKeyNotExists)
+	c.On("GetByID", mock.Anything, int64(3)).Return((*dto.CacheEntry)(nil), errors.New("connection refused"))
+
+	res, err := service.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, entry, res)
+
+	_, err = service.Get(context.Background(), 2)
+	require.ErrorIs(t, err, ErrEntryNotFound)
+
+	_, err = service.Get(context.Background(), 3)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrEntryNotFound)
+}
+
+func TestCacheService_Delete(t *testing.T) {
+	service, c := setup()
+
+	c.On("Delete", mock.Anything, int64(1)).Return(nil)
+	c.On("Delete", mock.Anything, int64(2)).Return(cachepkg.ErrKeyNotExists)
+
+	require.NoError(t, service.Delete(context.Background(), 1))
+	require.ErrorIs(t, service.Delete(context.Background(), 2), ErrEntryNotFound)
+}
+
+func TestCacheService_Purge(t *testing.T) {
+	service, c := setup()
+
+	c.On("Purge", mock.Anything).Return(2, nil)
+
+	n, err := service.Purge(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+}
+
+func TestCacheService_ClearExpired(t *testing.T) {
+	service, c := setup()
+
+	c.On("Clear", mock.Anything).Return(3)
+
+	require.Equal(t, 3, service.ClearExpired(context.Background()))
+}
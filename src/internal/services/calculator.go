@@ -3,101 +3,276 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
-	"math"
 	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/clock"
+	"mortgage-calculator/src/internal/lib/money"
+	"mortgage-calculator/src/internal/lib/tracing"
+	"mortgage-calculator/src/internal/logger"
 	"time"
 )
 
 // CalculatorService provides api for calculating aggregates.
 type CalculatorService struct {
-	log *slog.Logger
+	log      *slog.Logger
+	list     []dto.Program
+	programs map[string]dto.Program
+	rules    []Rule
+	currency money.Currency
+	rounding money.Rounding
+	clock    clock.Clock
 }
 
+// DefaultCurrency is used when currency is not configured.
+var DefaultCurrency = money.Currency{Code: "RUB", MinorUnits: 2}
+
+// DefaultRounding is used when rounding mode is not configured.
+const DefaultRounding = money.RoundHalfUp
+
 // NewCalculatorService is a constructor for CalculatorService.
-func NewCalculatorService(log *slog.Logger) *CalculatorService {
+// DefaultPrograms, DefaultCurrency, DefaultRounding and system clock are used when corresponding arguments are empty.
+// All amounts are treated as minor units of the currency.
+func NewCalculatorService(
+	log *slog.Logger,
+	programs []dto.Program,
+	currency money.Currency,
+	rounding money.Rounding,
+	clk clock.Clock,
+) *CalculatorService {
+	if len(programs) == 0 {
+		programs = DefaultPrograms()
+	}
+	if currency.Code == "" {
+		currency = DefaultCurrency
+	}
+	if rounding == "" {
+		rounding = DefaultRounding
+	}
+	if clk == nil {
+		clk = clock.System{}
+	}
+
+	byID := make(map[string]dto.Program, len(programs))
+	for _, p := range programs {
+		byID[p.ID] = p
+	}
+
 	return &CalculatorService{
-		log: log,
+		log:      log,
+		list:     programs,
+		programs: byID,
+		rules:    DefaultRules(),
+		currency: currency,
+		rounding: rounding,
+		clock:    clk,
+	}
+}
+
+// Programs returns available programs in configured order.
+func (s *CalculatorService) Programs() []dto.Program {
+	return append([]dto.Program(nil), s.list...)
+}
+
+// Fingerprint identifies configuration results of calculations depend on: programs, currency and rounding.
+func (s *CalculatorService) Fingerprint() string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%+v|%+v|%s", s.list, s.currency, s.rounding)
+
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// DefaultPrograms returns programs available when none are configured.
+func DefaultPrograms() []dto.Program {
+	return []dto.Program{
+		{ID: dto.ProgramSalary, Name: "Salary", Rate: 0.08, MinInitialPaymentRatio: 0.2},
+		{ID: dto.ProgramMilitary, Name: "Military", Rate: 0.09, MinInitialPaymentRatio: 0.2},
+		{ID: dto.ProgramBase, Name: "Base", Rate: 0.1, MinInitialPaymentRatio: 0.2},
 	}
 }
 
-// ErrInsufficientInitialPayment represents error when the initial payment to object cost ratio is too small.
-var ErrInsufficientInitialPayment = errors.New("the initial payment should be more")
+// ErrUnknownProgram represents error when chosen program is not configured.
+var ErrUnknownProgram = errors.New("unknown program")
 
-const minInitialPaymentRatio = 0.2
+// ErrBadStartDate represents error when start date is not a valid date.
+var ErrBadStartDate = errors.New("start date should be a date in YYYY-MM-DD format")
+
+// ErrAmountOutOfRange represents error when calculated amounts are too large to be represented.
+var ErrAmountOutOfRange = errors.New("calculated amounts are out of range")
 
 // Calculate calculates aggregates based on params and program.
 func (s *CalculatorService) Calculate(
-	_ context.Context,
+	ctx context.Context,
 	params dto.CalcParams,
 	program dto.CalcProgram,
 ) (*dto.CalcAggregates, error) {
 	const op = "calculatorService.Calculate"
-	log := s.log.With(slog.String("op", op))
+	log := logger.FromContext(ctx, s.log).With(slog.String("op", op))
 
-	if float64(params.InitialPayment)/float64(params.ObjectCost) < minInitialPaymentRatio {
-		log.Warn(
-			"insufficient initial payment",
-			slog.Int("initial_payment", params.InitialPayment),
-			slog.Int("object_cost", params.ObjectCost),
-		)
+	_, span := tracing.Start(ctx, "CalculatorService.Calculate",
+		tracing.String("program", program.Key()),
+		tracing.Int("months", params.Months),
+		tracing.String("payment_type", paymentTypeOf(params)),
+	)
+	defer span.End()
 
-		return nil, fmt.Errorf("%s: %w", op, ErrInsufficientInitialPayment)
+	res, err := s.calculate(log, params, program)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	log.Info("calculating aggregates")
+	return &res.Aggregates, nil
+}
 
-	months := float64(params.Months)
-	objectCost := float64(params.ObjectCost)
-	initialPayment := float64(params.InitialPayment)
+// Schedule calculates aggregates and month-by-month amortization schedule based on params and program.
+// Schedule totals always reconcile with aggregates returned by Calculate for the same input.
+func (s *CalculatorService) Schedule(
+	ctx context.Context,
+	params dto.CalcParams,
+	program dto.CalcProgram,
+) (*dto.CalcSchedule, error) {
+	const op = "calculatorService.Schedule"
+	log := logger.FromContext(ctx, s.log).With(slog.String("op", op))
 
-	annualRate := getAnnualRate(program)
-	G := annualRate / 12             // monthly rate
-	S := objectCost - initialPayment // mortgage debt (loan sum)
+	res, err := s.calculate(log, params, program)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-	lastPaymentDate := time.Now().AddDate(0, params.Months, 0)
-	T := months // interest periods count
+	return res, nil
+}
 
-	totalRate := math.Pow(1+G, T)
-	PM := math.Ceil(S * G * totalRate / (totalRate - 1)) // monthly payment
+func (s *CalculatorService) calculate(
+	log *slog.Logger,
+	params dto.CalcParams,
+	program dto.CalcProgram,
+) (*dto.CalcSchedule, error) {
+	p, err := s.program(log, program)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkRules(log, p, params); err != nil {
+		return nil, err
+	}
+
+	start, err := s.startDate(log, &params)
+	if err != nil {
+		return nil, err
+	}
 
-	overpayment := PM*months - S
+	paymentType := paymentTypeOf(params)
+
+	log.Info(
+		"calculating aggregates",
+		slog.String("payment_type", paymentType),
+		slog.Int("early_repayments", len(params.EarlyRepayments)),
+	)
+
+	l := s.newLoan(params, p, start)
+	res, err := s.result(log, l, paymentType)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(params.EarlyRepayments) > 0 {
+		l.repayments = nil
+		base, err := s.result(log, l, paymentType)
+		if err != nil {
+			return nil, err
+		}
+		res.Aggregates.SavedInterest = base.Aggregates.Overpayment - res.Aggregates.Overpayment
+	}
 
 	log.Info(
 		"aggregates calculated",
-		slog.Any("lastPaymentDate", lastPaymentDate),
-		slog.Float64("annualRate", annualRate),
-		slog.Float64("G", G),
-		slog.Float64("S", S),
-		slog.Float64("T", T),
-		slog.Float64("PM", PM),
-		slog.Float64("overpayment", overpayment),
+		slog.String("lastPaymentDate", res.Aggregates.LastPaymentDate),
+		slog.Int64("rateBps", int64(l.rate)),
+		slog.String("rounding", string(l.rounding)),
+		slog.Int64("S", int64(l.S)),
+		slog.Int("T", l.T),
+		slog.Int64("PM", int64(res.Aggregates.MonthlyPayment)),
+		slog.Int64("overpayment", int64(res.Aggregates.Overpayment)),
+		slog.Int64("savedInterest", int64(res.Aggregates.SavedInterest)),
 	)
 
-	return &dto.CalcAggregates{
-		LastPaymentDate: lastPaymentDate.Format("2006-01-02"),
-		Rate:            int(annualRate * 100),
-		LoanSum:         int(S),
-		MonthlyPayment:  int(PM),
-		Overpayment:     int(overpayment),
-	}, nil
+	return res, nil
+}
+
+// newLoan creates loan of params with program rate and service rounding.
+func (s *CalculatorService) newLoan(params dto.CalcParams, p dto.Program, start time.Time) *loan {
+	return newLoan(params, money.NewRate(p.Rate), s.rounding, start)
 }
 
-const salaryRate = 0.08
-const militaryRate = 0.09
-const baseRate = 0.1
-
-func getAnnualRate(program dto.CalcProgram) float64 {
-	switch {
-	case program.Salary:
-		return salaryRate
-	case program.Military:
-		return militaryRate
-	case program.Base:
-		return baseRate
-	default:
-		return 0
+// result builds schedule of loan, amounts not fitting money.Amount are reported as ErrAmountOutOfRange.
+func (s *CalculatorService) result(log *slog.Logger, l *loan, paymentType string) (*dto.CalcSchedule, error) {
+	res, err := l.result(paymentType, s.currency)
+	if err != nil {
+		log.Warn("calculated amounts are out of range", slog.Int64("S", int64(l.S)), slog.Int("T", l.T))
+
+		return nil, ErrAmountOutOfRange
+	}
+
+	return res, nil
+}
+
+// startDate parses issue date of params and fills it with today's date of service clock when it is empty.
+func (s *CalculatorService) startDate(log *slog.Logger, params *dto.CalcParams) (time.Time, error) {
+	if params.StartDate == "" {
+		now := s.clock.Now()
+		params.StartDate = now.Format(dto.DateFormat)
+
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+
+	start, err := time.Parse(dto.DateFormat, params.StartDate)
+	if err != nil {
+		log.Warn("invalid start date", slog.String("start_date", params.StartDate))
+
+		return time.Time{}, ErrBadStartDate
 	}
+
+	return start, nil
+}
+
+// program finds configured program chosen by user.
+func (s *CalculatorService) program(log *slog.Logger, program dto.CalcProgram) (dto.Program, error) {
+	p, ok := s.programs[program.Key()]
+	if !ok {
+		log.Warn("unknown program", slog.String("program", program.Key()))
+
+		return dto.Program{}, ErrUnknownProgram
+	}
+
+	return p, nil
+}
+
+// checkRules evaluates service rules and logs violations.
+func (s *CalculatorService) checkRules(log *slog.Logger, p dto.Program, params dto.CalcParams) error {
+	err := checkRules(s.rules, p, params)
+	if err != nil {
+		log.Warn(
+			"program rules violated",
+			slog.String("program", p.ID),
+			slog.Int64("initial_payment", int64(params.InitialPayment)),
+			slog.Int64("object_cost", int64(params.ObjectCost)),
+			slog.Int("months", params.Months),
+			slog.Any("error", err),
+		)
+	}
+
+	return err
+}
+
+// paymentTypeOf returns payment type of params, annuity is used by default.
+func paymentTypeOf(params dto.CalcParams) string {
+	if params.PaymentType == "" {
+		return dto.PaymentTypeAnnuity
+	}
+
+	return params.PaymentType
 }
@@ -2,16 +2,22 @@ package services
 
 import (
 	"context"
+	"github.com/stretchr/testify/require"
 	"io"
 	"log/slog"
 	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/clock"
+	"mortgage-calculator/src/internal/lib/money"
 	"testing"
 	"time"
 )
 
+// testClock pins the date of calculations.
+var testClock = clock.Fixed(time.Date(2024, 6, 18, 15, 4, 5, 0, time.UTC))
+
 func Test_NewCalculatorService(t *testing.T) {
 	log := slog.Logger{}
-	service := NewCalculatorService(&log)
+	service := NewCalculatorService(&log, nil, DefaultCurrency, DefaultRounding, testClock)
 
 	if service == nil {
 		t.Fatalf("calculator service is nil")
@@ -19,7 +25,7 @@ func Test_NewCalculatorService(t *testing.T) {
 }
 
 func TestCalculatorService_Calculate_HappyPath(t *testing.T) {
-	now := time.Now()
+	now := testClock.Now()
 
 	cases := []struct {
 		params  dto.CalcParams
@@ -35,10 +41,16 @@ func TestCalculatorService_Calculate_HappyPath(t *testing.T) {
 			dto.CalcProgram{Salary: true},
 			&dto.CalcAggregates{
 				LastPaymentDate: now.AddDate(0, 240, 0).Format("2006-01-02"),
+				PaymentType:     "annuity",
+				Currency:        DefaultCurrency,
 				Rate:            8,
+				RateBps:         800,
 				LoanSum:         4000000,
 				MonthlyPayment:  33458,
-				Overpayment:     4029920,
+				FirstPayment:    33458,
+				LastPayment:     33245,
+				MaxPayment:      33458,
+				Overpayment:     4029707,
 			},
 		},
 		{
@@ -50,10 +62,16 @@ func TestCalculatorService_Calculate_HappyPath(t *testing.T) {
 			dto.CalcProgram{Military: true},
 			&dto.CalcAggregates{
 				LastPaymentDate: now.AddDate(0, 12, 0).Format("2006-01-02"),
+				PaymentType:     "annuity",
+				Currency:        DefaultCurrency,
 				Rate:            9,
+				RateBps:         900,
 				LoanSum:         80,
 				MonthlyPayment:  7,
-				Overpayment:     4,
+				FirstPayment:    7,
+				LastPayment:     6,
+				MaxPayment:      7,
+				Overpayment:     3,
 			},
 		},
 		{
@@ -65,17 +83,23 @@ func TestCalculatorService_Calculate_HappyPath(t *testing.T) {
 			dto.CalcProgram{Base: true},
 			&dto.CalcAggregates{
 				LastPaymentDate: now.AddDate(0, 1200, 0).Format("2006-01-02"),
+				PaymentType:     "annuity",
+				Currency:        DefaultCurrency,
 				Rate:            10,
+				RateBps:         1000,
 				LoanSum:         80000000000,
 				MonthlyPayment:  666698216,
-				Overpayment:     720037859200,
+				FirstPayment:    666698216,
+				LastPayment:     664874195,
+				MaxPayment:      666698216,
+				Overpayment:     720036035179,
 			},
 		},
 	}
 
 	ctx := context.Background()
 	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
-	service := NewCalculatorService(log)
+	service := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, testClock)
 
 	for _, tt := range cases {
 		res, err := service.Calculate(ctx, tt.params, tt.program)
@@ -88,10 +112,91 @@ func TestCalculatorService_Calculate_HappyPath(t *testing.T) {
 	}
 }
 
+func TestCalculatorService_Calculate_FractionalRate(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, []dto.Program{{ID: "family", Rate: 0.085}}, DefaultCurrency, DefaultRounding, testClock)
+
+	res, err := service.Calculate(ctx, dto.CalcParams{
+		ObjectCost:     500000000,
+		InitialPayment: 100000000,
+		Months:         240,
+	}, dto.CalcProgram{ID: "family"})
+	require.NoError(t, err)
+
+	require.Equal(t, 8.5, res.Rate)
+	require.Equal(t, money.Rate(850), res.RateBps)
+	require.Equal(t, money.Amount(3471293), res.MonthlyPayment)
+	require.Equal(t, money.Amount(3471236), res.LastPayment)
+	require.Equal(t, money.Amount(433110263), res.Overpayment)
+}
+
+func TestCalculatorService_Calculate_Rounding(t *testing.T) {
+	cases := []struct {
+		rounding     money.Rounding
+		firstPayment money.Amount
+		overpayment  money.Amount
+	}{
+		{money.RoundUp, 128, 5},
+		{money.RoundDown, 127, 3},
+		{money.RoundHalfUp, 128, 4},
+		{money.RoundHalfEven, 127, 3},
+	}
+
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+
+	// interest of the first month is exactly 2.5
+	params := dto.CalcParams{
+		ObjectCost:     350,
+		InitialPayment: 100,
+		Months:         2,
+		PaymentType:    dto.PaymentTypeDifferentiated,
+	}
+
+	for _, tt := range cases {
+		service := NewCalculatorService(log, []dto.Program{{ID: "base", Rate: 0.12}}, DefaultCurrency, tt.rounding, testClock)
+
+		res, err := service.Calculate(ctx, params, dto.CalcProgram{ID: "base"})
+		require.NoError(t, err)
+		require.Equal(t, tt.firstPayment, res.FirstPayment, tt.rounding)
+		require.Equal(t, tt.overpayment, res.Overpayment, tt.rounding)
+	}
+}
+
+func TestCalculatorService_Schedule_StartDate(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, testClock)
+
+	params := dto.CalcParams{
+		ObjectCost:     1000,
+		InitialPayment: 300,
+		Months:         3,
+		StartDate:      "2023-12-15",
+	}
+
+	res, err := service.Schedule(ctx, params, dto.CalcProgram{Base: true})
+	require.NoError(t, err)
+	require.Equal(t, "2024-01-15", res.Payments[0].PaymentDate)
+	require.Equal(t, "2024-03-15", res.Aggregates.LastPaymentDate)
+
+	// result doesn't depend on the clock
+	other := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, clock.Fixed(time.Now()))
+	same, err := other.Schedule(ctx, params, dto.CalcProgram{Base: true})
+	require.NoError(t, err)
+	require.Equal(t, res, same)
+
+	params.StartDate = "2023-02-30"
+	res, err = service.Schedule(ctx, params, dto.CalcProgram{Base: true})
+	require.ErrorIs(t, err, ErrBadStartDate)
+	require.Empty(t, res)
+}
+
 func TestCalculatorService_Calculate_InsufficientInitialPayment(t *testing.T) {
 	ctx := context.Background()
 	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
-	service := NewCalculatorService(log)
+	service := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, testClock)
 
 	res, err := service.Calculate(
 		ctx,
@@ -111,20 +216,402 @@ func TestCalculatorService_Calculate_InsufficientInitialPayment(t *testing.T) {
 	}
 }
 
-func TestGetAnnualRate(t *testing.T) {
+func TestCalculatorService_Schedule(t *testing.T) {
+	cases := []struct {
+		params  dto.CalcParams
+		program dto.CalcProgram
+	}{
+		{
+			dto.CalcParams{
+				ObjectCost:     5000000,
+				InitialPayment: 1000000,
+				Months:         240,
+			},
+			dto.CalcProgram{Salary: true},
+		},
+		{
+			dto.CalcParams{
+				ObjectCost:     100,
+				InitialPayment: 20,
+				Months:         12,
+			},
+			dto.CalcProgram{Military: true},
+		},
+		{
+			dto.CalcParams{
+				ObjectCost:     100000000000,
+				InitialPayment: 20000000000,
+				Months:         1200,
+			},
+			dto.CalcProgram{Base: true},
+		},
+	}
+
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, testClock)
+
+	for _, tt := range cases {
+		agg, err := service.Calculate(ctx, tt.params, tt.program)
+		require.NoError(t, err)
+
+		res, err := service.Schedule(ctx, tt.params, tt.program)
+		require.NoError(t, err)
+
+		require.Equal(t, *agg, res.Aggregates)
+		require.Len(t, res.Payments, tt.params.Months)
+
+		var payment, principal, interest money.Amount
+		for _, p := range res.Payments {
+			require.Equal(t, p.Payment, p.Principal+p.Interest)
+			require.GreaterOrEqual(t, p.Interest, money.Amount(0))
+			payment += p.Payment
+			principal += p.Principal
+			interest += p.Interest
+		}
+
+		last := res.Payments[len(res.Payments)-1]
+		require.Equal(t, money.Amount(0), last.Balance)
+		require.Equal(t, agg.LastPaymentDate, last.PaymentDate)
+
+		require.Equal(t, dto.ScheduleTotals{Payment: payment, Principal: principal, Interest: interest}, res.Totals)
+		require.Equal(t, agg.LoanSum, res.Totals.Principal)
+		require.Equal(t, agg.Overpayment, res.Totals.Interest)
+		require.Equal(t, agg.MonthlyPayment*money.Amount(tt.params.Months-1)+agg.LastPayment, res.Totals.Payment)
+	}
+}
+
+func TestCalculatorService_Calculate_Differentiated(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, testClock)
+
+	params := dto.CalcParams{
+		ObjectCost:     5000000,
+		InitialPayment: 1000000,
+		Months:         240,
+		PaymentType:    dto.PaymentTypeDifferentiated,
+	}
+
+	res, err := service.Calculate(ctx, params, dto.CalcProgram{Salary: true})
+	require.NoError(t, err)
+
+	require.Equal(t, &dto.CalcAggregates{
+		LastPaymentDate: testClock.Now().AddDate(0, 240, 0).Format("2006-01-02"),
+		PaymentType:     dto.PaymentTypeDifferentiated,
+		Currency:        DefaultCurrency,
+		Rate:            8,
+		RateBps:         800,
+		LoanSum:         4000000,
+		MonthlyPayment:  43333,
+		FirstPayment:    43333,
+		LastPayment:     16938,
+		MaxPayment:      43333,
+		Overpayment:     3213460,
+	}, res)
+
+	annuity, err := service.Calculate(ctx, dto.CalcParams{
+		ObjectCost:     params.ObjectCost,
+		InitialPayment: params.InitialPayment,
+		Months:         params.Months,
+	}, dto.CalcProgram{Salary: true})
+	require.NoError(t, err)
+
+	// differentiated payments always start higher and cost less than annuity
+	require.Greater(t, res.FirstPayment, annuity.FirstPayment)
+	require.Less(t, res.Overpayment, annuity.Overpayment)
+}
+
+func TestCalculatorService_Schedule_Differentiated(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, testClock)
+
+	params := dto.CalcParams{
+		ObjectCost:     1000,
+		InitialPayment: 300,
+		Months:         3,
+		PaymentType:    dto.PaymentTypeDifferentiated,
+	}
+
+	res, err := service.Schedule(ctx, params, dto.CalcProgram{Base: true})
+	require.NoError(t, err)
+
+	now := testClock.Now()
+	require.Equal(t, []dto.SchedulePayment{
+		{Month: 1, PaymentDate: now.AddDate(0, 1, 0).Format("2006-01-02"), Payment: 239, Principal: 233, Interest: 6, Balance: 467},
+		{Month: 2, PaymentDate: now.AddDate(0, 2, 0).Format("2006-01-02"), Payment: 237, Principal: 233, Interest: 4, Balance: 234},
+		{Month: 3, PaymentDate: now.AddDate(0, 3, 0).Format("2006-01-02"), Payment: 236, Principal: 234, Interest: 2, Balance: 0},
+	}, res.Payments)
+	require.Equal(t, dto.ScheduleTotals{Payment: 712, Principal: 700, Interest: 12}, res.Totals)
+	require.Equal(t, money.Amount(12), res.Aggregates.Overpayment)
+	require.Equal(t, money.Amount(239), res.Aggregates.MaxPayment)
+	require.Equal(t, money.Amount(236), res.Aggregates.LastPayment)
+}
+
+func TestCalculatorService_Schedule_EarlyRepayments(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, testClock)
+
+	cases := []struct {
+		paymentType string
+		strategy    string
+	}{
+		{dto.PaymentTypeAnnuity, dto.StrategyReduceTerm},
+		{dto.PaymentTypeAnnuity, dto.StrategyReducePayment},
+		{dto.PaymentTypeDifferentiated, dto.StrategyReduceTerm},
+		{dto.PaymentTypeDifferentiated, dto.StrategyReducePayment},
+	}
+
+	for _, tt := range cases {
+		params := dto.CalcParams{
+			ObjectCost:     5000000,
+			InitialPayment: 1000000,
+			Months:         240,
+			PaymentType:    tt.paymentType,
+		}
+
+		base, err := service.Schedule(ctx, params, dto.CalcProgram{Base: true})
+		require.NoError(t, err)
+
+		params.EarlyRepayments = []dto.EarlyRepayment{
+			{Month: 12, Amount: 500000, Strategy: tt.strategy},
+			{Month: 24, Amount: 500000, Strategy: tt.strategy},
+		}
+
+		res, err := service.Schedule(ctx, params, dto.CalcProgram{Base: true})
+		require.NoError(t, err)
+
+		require.Equal(t, money.Amount(1000000), res.Totals.EarlyRepayment)
+		require.Equal(t, money.Amount(500000), res.Payments[11].EarlyRepayment)
+		require.Equal(t, res.Aggregates.LoanSum, res.Totals.Principal+res.Totals.EarlyRepayment)
+		require.Equal(t, res.Aggregates.Overpayment, res.Totals.Interest)
+		require.Equal(t, base.Aggregates.Overpayment-res.Aggregates.Overpayment, res.Aggregates.SavedInterest)
+		require.Positive(t, res.Aggregates.SavedInterest)
+
+		last := res.Payments[len(res.Payments)-1]
+		require.Equal(t, money.Amount(0), last.Balance)
+		require.Equal(t, last.PaymentDate, res.Aggregates.LastPaymentDate)
+
+		switch tt.strategy {
+		case dto.StrategyReduceTerm:
+			require.Less(t, len(res.Payments), params.Months)
+			require.Less(t, res.Aggregates.LastPaymentDate, base.Aggregates.LastPaymentDate)
+			// annuity keeps payment, differentiated keeps principal part
+			if tt.paymentType == dto.PaymentTypeAnnuity {
+				require.Equal(t, base.Payments[12].Payment, res.Payments[12].Payment)
+			} else {
+				require.Equal(t, base.Payments[12].Principal, res.Payments[12].Principal)
+			}
+		case dto.StrategyReducePayment:
+			require.Len(t, res.Payments, params.Months)
+			require.Equal(t, base.Aggregates.LastPaymentDate, res.Aggregates.LastPaymentDate)
+			require.Less(t, res.Payments[12].Payment, base.Payments[12].Payment)
+		}
+	}
+}
+
+func TestCalculatorService_Schedule_EarlyRepaymentClosesLoan(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, testClock)
+
+	res, err := service.Schedule(ctx, dto.CalcParams{
+		ObjectCost:     5000000,
+		InitialPayment: 1000000,
+		Months:         240,
+		EarlyRepayments: []dto.EarlyRepayment{
+			{Month: 6, Amount: 10000000, Strategy: dto.StrategyReducePayment},
+		},
+	}, dto.CalcProgram{Base: true})
+	require.NoError(t, err)
+
+	require.Len(t, res.Payments, 6)
+	require.Equal(t, money.Amount(0), res.Payments[5].Balance)
+	require.Equal(t, res.Aggregates.LoanSum, res.Totals.Principal+res.Totals.EarlyRepayment)
+	require.Equal(t, testClock.Now().AddDate(0, 6, 0).Format("2006-01-02"), res.Aggregates.LastPaymentDate)
+}
+
+func TestCalculatorService_ZeroLoanSum(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, testClock)
+
+	for _, paymentType := range []string{dto.PaymentTypeAnnuity, dto.PaymentTypeDifferentiated} {
+		params := dto.CalcParams{ObjectCost: 5000000, InitialPayment: 5000000, Months: 12, PaymentType: paymentType}
+
+		_, err := service.Calculate(ctx, params, dto.CalcProgram{Base: true})
+		require.ErrorIs(t, err, ErrLoanSumOutOfRange)
+
+		_, err = service.Schedule(ctx, params, dto.CalcProgram{Base: true})
+		require.ErrorIs(t, err, ErrLoanSumOutOfRange)
+	}
+}
+
+func TestCalculatorService_AmountOutOfRange(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, []dto.Program{
+		{ID: "usury", Rate: 300},
+		{ID: "extortion", Rate: 120000},
+	}, DefaultCurrency, DefaultRounding, testClock)
+
 	cases := []struct {
-		in   dto.CalcProgram
-		want float64
+		program     string
+		paymentType string
 	}{
-		{dto.CalcProgram{Salary: true}, 0.08},
-		{dto.CalcProgram{Military: true}, 0.09},
-		{dto.CalcProgram{Base: true}, 0.10},
-		{dto.CalcProgram{}, 0},
+		{"usury", dto.PaymentTypeDifferentiated},     // total interest doesn't fit money.Amount
+		{"extortion", dto.PaymentTypeAnnuity},        // monthly payment doesn't fit money.Amount
+		{"extortion", dto.PaymentTypeDifferentiated}, // monthly interest doesn't fit money.Amount
 	}
 
 	for _, tt := range cases {
-		if res := getAnnualRate(tt.in); res != tt.want {
-			t.Fatalf("want %f, got %f", tt.want, res)
+		params := dto.CalcParams{ObjectCost: dto.MaxAmount, InitialPayment: 1, Months: dto.MaxMonths, PaymentType: tt.paymentType}
+
+		_, err := service.Calculate(ctx, params, dto.CalcProgram{ID: tt.program})
+		require.ErrorIs(t, err, ErrAmountOutOfRange, tt)
+
+		_, err = service.Schedule(ctx, params, dto.CalcProgram{ID: tt.program})
+		require.ErrorIs(t, err, ErrAmountOutOfRange, tt)
+	}
+}
+
+func TestLoan_Result_Empty(t *testing.T) {
+	for _, paymentType := range []string{dto.PaymentTypeAnnuity, dto.PaymentTypeDifferentiated} {
+		l := newLoan(dto.CalcParams{Months: 12}, 1000, DefaultRounding, testClock.Now())
+
+		res, err := l.result(paymentType, DefaultCurrency)
+		require.NoError(t, err)
+		require.Empty(t, res.Payments)
+		require.Equal(t, money.Amount(0), res.Aggregates.MonthlyPayment)
+		require.Equal(t, money.Amount(0), res.Aggregates.Overpayment)
+	}
+}
+
+func TestCalculatorService_Schedule_InsufficientInitialPayment(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, testClock)
+
+	res, err := service.Schedule(
+		ctx,
+		dto.CalcParams{
+			ObjectCost:     5000000,
+			InitialPayment: 999999,
+			Months:         240,
+		},
+		dto.CalcProgram{Base: true},
+	)
+
+	require.Error(t, err)
+	require.ErrorIs(t, err, ErrInsufficientInitialPayment)
+	require.Empty(t, res)
+}
+
+func TestNewCalculatorService_Defaults(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, nil, money.Currency{}, "", nil)
+
+	require.Len(t, service.programs, len(DefaultPrograms()))
+	for _, p := range DefaultPrograms() {
+		require.Equal(t, p, service.programs[p.ID])
+	}
+	require.Equal(t, DefaultCurrency, service.currency)
+	require.Equal(t, DefaultRounding, service.rounding)
+}
+
+func TestCalculatorService_Programs(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	programs := []dto.Program{
+		{ID: "family", Rate: 0.06},
+		{ID: "base", Rate: 0.1},
+	}
+	service := NewCalculatorService(log, programs, DefaultCurrency, DefaultRounding, testClock)
+
+	res := service.Programs()
+	require.Equal(t, programs, res)
+
+	// returned slice must not share memory with service
+	res[0].Rate = 1
+	require.Equal(t, 0.06, service.Programs()[0].Rate)
+}
+
+func TestCalculatorService_Fingerprint(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	fingerprint := func(programs []dto.Program, currency money.Currency, rounding money.Rounding) string {
+		return NewCalculatorService(log, programs, currency, rounding, testClock).Fingerprint()
+	}
+
+	base := fingerprint(DefaultPrograms(), DefaultCurrency, DefaultRounding)
+	require.Equal(t, base, fingerprint(DefaultPrograms(), DefaultCurrency, DefaultRounding))
+
+	changed := DefaultPrograms()
+	changed[0].Rate = 0.07
+	require.NotEqual(t, base, fingerprint(changed, DefaultCurrency, DefaultRounding))
+	require.NotEqual(t, base, fingerprint(DefaultPrograms(), money.Currency{Code: "USD", MinorUnits: 2}, DefaultRounding))
+	require.NotEqual(t, base, fingerprint(DefaultPrograms(), DefaultCurrency, money.RoundHalfEven))
+}
+
+func TestCalculatorService_Calculate_ProgramID(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, testClock)
+
+	params := dto.CalcParams{
+		ObjectCost:     5000000,
+		InitialPayment: 1000000,
+		Months:         240,
+	}
+
+	byID, err := service.Calculate(ctx, params, dto.CalcProgram{ID: dto.ProgramMilitary})
+	require.NoError(t, err)
+
+	byFlag, err := service.Calculate(ctx, params, dto.CalcProgram{Military: true})
+	require.NoError(t, err)
+
+	require.Equal(t, byFlag, byID)
+	require.Equal(t, 9.0, byID.Rate)
+}
+
+func TestCalculatorService_Calculate_ProgramLimits(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, []dto.Program{
+		{
+			ID:                     "family",
+			Rate:                   0.06,
+			MinInitialPaymentRatio: 0.1,
+			MinMonths:              12,
+			MaxMonths:              360,
+			MinLoan:                100000,
+			MaxLoan:                6000000,
+		},
+	}, DefaultCurrency, DefaultRounding, testClock)
+
+	cases := []struct {
+		params  dto.CalcParams
+		program dto.CalcProgram
+		err     error
+	}{
+		{dto.CalcParams{ObjectCost: 1000000, InitialPayment: 100000, Months: 120}, dto.CalcProgram{ID: "family"}, nil},
+		{dto.CalcParams{ObjectCost: 1000000, InitialPayment: 99999, Months: 120}, dto.CalcProgram{ID: "family"}, ErrInsufficientInitialPayment},
+		{dto.CalcParams{ObjectCost: 1000000, InitialPayment: 100000, Months: 11}, dto.CalcProgram{ID: "family"}, ErrTermOutOfRange},
+		{dto.CalcParams{ObjectCost: 1000000, InitialPayment: 100000, Months: 361}, dto.CalcProgram{ID: "family"}, ErrTermOutOfRange},
+		{dto.CalcParams{ObjectCost: 100000, InitialPayment: 20000, Months: 120}, dto.CalcProgram{ID: "family"}, ErrLoanSumOutOfRange},
+		{dto.CalcParams{ObjectCost: 10000000, InitialPayment: 3000000, Months: 120}, dto.CalcProgram{ID: "family"}, ErrLoanSumOutOfRange},
+		{dto.CalcParams{ObjectCost: 1000000, InitialPayment: 200000, Months: 120}, dto.CalcProgram{Base: true}, ErrUnknownProgram},
+	}
+
+	for _, tt := range cases {
+		res, err := service.Calculate(ctx, tt.params, tt.program)
+		if tt.err == nil {
+			require.NoError(t, err)
+			require.NotEmpty(t, res)
+			continue
 		}
+
+		require.ErrorIs(t, err, tt.err)
+		require.Empty(t, res)
 	}
 }
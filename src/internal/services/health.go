@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/logger"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultHealthTimeout limits every health check by default.
+const DefaultHealthTimeout = 2 * time.Second
+
+var (
+	errNotStarted   = errors.New("application is not started")
+	errShuttingDown = errors.New("application is shutting down")
+)
+
+// HealthChecker reports whether component is able to serve requests.
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// HealthCheckerFunc adapts function to HealthChecker.
+type HealthCheckerFunc func(ctx context.Context) error
+
+// Check calls f.
+func (f HealthCheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Application states reported by readiness.
+const (
+	stateStarting int32 = iota
+	stateRunning
+	stateStopping
+)
+
+// HealthService reports liveness and readiness of application.
+// Application is ready when it is running and every registered checker succeeds.
+type HealthService struct {
+	log      *slog.Logger
+	timeout  time.Duration
+	state    atomic.Int32
+	mu       sync.RWMutex
+	checkers map[string]HealthChecker
+}
+
+// NewHealthService is a constructor for HealthService.
+// Non-positive timeout means DefaultHealthTimeout.
+func NewHealthService(
+	log *slog.Logger,
+	timeout time.Duration,
+) *HealthService {
+	if timeout <= 0 {
+		timeout = DefaultHealthTimeout
+	}
+
+	return &HealthService{
+		log:      log,
+		timeout:  timeout,
+		checkers: make(map[string]HealthChecker),
+	}
+}
+
+// Register adds checker of component, checker with the same name is replaced.
+func (s *HealthService) Register(name string, checker HealthChecker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkers[name] = checker
+}
+
+// MarkRunning reports that application has started.
+func (s *HealthService) MarkRunning() {
+	s.state.Store(stateRunning)
+}
+
+// MarkStopping reports that application is shutting down, so it is not ready anymore.
+func (s *HealthService) MarkStopping() {
+	s.state.Store(stateStopping)
+}
+
+// Live reports that process is alive, it doesn't check components.
+func (s *HealthService) Live(_ context.Context) *dto.Health {
+	return &dto.Health{Status: dto.HealthStatusOK}
+}
+
+// Ready runs all checkers concurrently and reports their statuses.
+func (s *HealthService) Ready(ctx context.Context) *dto.Health {
+	const op = "services.HealthService.Ready"
+	log := logger.FromContext(ctx, s.log).With(slog.String("op", op))
+
+	s.mu.RLock()
+	checks := make([]*dto.HealthCheck, 0, len(s.checkers)+1)
+	checkers := make([]HealthChecker, 0, len(s.checkers)+1)
+	for name, checker := range s.checkers {
+		checks = append(checks, &dto.HealthCheck{Name: name})
+		checkers = append(checkers, checker)
+	}
+	s.mu.RUnlock()
+
+	checks = append(checks, &dto.HealthCheck{Name: "app"})
+	checkers = append(checkers, HealthCheckerFunc(s.checkState))
+
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.run(ctx, checks[i], checkers[i])
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Name < checks[j].Name })
+
+	res := &dto.Health{Status: dto.HealthStatusOK, Checks: checks}
+	for _, check := range checks {
+		if check.Status != dto.HealthStatusOK {
+			res.Status = dto.HealthStatusFail
+			log.Warn("health check failed", slog.String("check", check.Name), slog.String("error", check.Error))
+		}
+	}
+
+	return res
+}
+
+// run calls checker within timeout and fills check result.
+func (s *HealthService) run(ctx context.Context, check *dto.HealthCheck, checker HealthChecker) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// checker ignoring context doesn't delay response beyond timeout
+	done := make(chan error, 1)
+	go func() {
+		done <- checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	check.DurationMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		check.Status = dto.HealthStatusFail
+		check.Error = err.Error()
+		return
+	}
+
+	check.Status = dto.HealthStatusOK
+}
+
+func (s *HealthService) checkState(_ context.Context) error {
+	switch s.state.Load() {
+	case stateStarting:
+		return errNotStarted
+	case stateStopping:
+		return errShuttingDown
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"mortgage-calculator/src/internal/domain/dto"
+	"testing"
+	"time"
+)
+
+func setupHealth(timeout time.Duration) *HealthService {
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	return NewHealthService(log, timeout)
+}
+
+func okChecker(context.Context) error { return nil }
+
+func TestHealthService_Live(t *testing.T) {
+	s := setupHealth(0)
+
+	require.Equal(t, &dto.Health{Status: dto.HealthStatusOK}, s.Live(context.Background()))
+}
+
+func TestHealthService_Ready(t *testing.T) {
+	s := setupHealth(0)
+	s.Register("cache", HealthCheckerFunc(okChecker))
+	s.Register("broken", HealthCheckerFunc(func(context.Context) error { return errors.New("connection refused") }))
+
+	// application is not ready until started
+	res := s.Ready(context.Background())
+	require.Equal(t, dto.HealthStatusFail, res.Status)
+	require.Len(t, res.Checks, 3)
+	require.Equal(t, "app", res.Checks[0].Name)
+	require.Equal(t, errNotStarted.Error(), res.Checks[0].Error)
+
+	s.MarkRunning()
+
+	res = s.Ready(context.Background())
+	require.Equal(t, dto.HealthStatusFail, res.Status)
+	require.Equal(t, []string{"app", "broken", "cache"}, []string{res.Checks[0].Name, res.Checks[1].Name, res.Checks[2].Name})
+	require.Equal(t, dto.HealthStatusOK, res.Checks[0].Status)
+	require.Equal(t, dto.HealthStatusFail, res.Checks[1].Status)
+	require.Equal(t, "connection refused", res.Checks[1].Error)
+	require.Equal(t, dto.HealthStatusOK, res.Checks[2].Status)
+
+	// registering checker with the same name replaces it
+	s.Register("broken", HealthCheckerFunc(okChecker))
+	require.Equal(t, dto.HealthStatusOK, s.Ready(context.Background()).Status)
+
+	s.MarkStopping()
+
+	res = s.Ready(context.Background())
+	require.Equal(t, dto.HealthStatusFail, res.Status)
+	require.Equal(t, errShuttingDown.Error(), res.Checks[0].Error)
+}
+
+func TestHealthService_Ready_Timeout(t *testing.T) {
+	s := setupHealth(10 * time.Millisecond)
+	s.MarkRunning()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	// checker ignoring context
+	s.Register("slow", HealthCheckerFunc(func(context.Context) error {
+		<-release
+		return nil
+	}))
+
+	res := s.Ready(context.Background())
+	require.Equal(t, dto.HealthStatusFail, res.Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), res.Checks[1].Error)
+}
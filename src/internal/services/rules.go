@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"mortgage-calculator/src/internal/domain/dto"
+	"strings"
+)
+
+// Violation codes returned by DefaultRules.
+const (
+	CodeInitialPaymentRange    = "initial_payment_range"
+	CodeMinInitialPaymentRatio = "min_initial_payment_ratio"
+	CodeMinMonths              = "min_months"
+	CodeMaxMonths              = "max_months"
+	CodeMinLoan                = "min_loan"
+	CodeMaxLoan                = "max_loan"
+	CodeMaxObjectCost          = "max_object_cost"
+)
+
+// ErrNotEligible represents error when params do not satisfy program rules.
+var ErrNotEligible = errors.New("params do not satisfy program rules")
+
+// ErrInitialPaymentOutOfRange represents error when the initial payment is negative or covers the whole object cost.
+var ErrInitialPaymentOutOfRange = errors.New("the initial payment should be non-negative and less than the object cost")
+
+// ErrInsufficientInitialPayment represents error when the initial payment to object cost ratio is too small.
+var ErrInsufficientInitialPayment = errors.New("the initial payment should be more")
+
+// ErrTermOutOfRange represents error when months count is out of program limits.
+var ErrTermOutOfRange = errors.New("the term is out of program limits")
+
+// ErrLoanSumOutOfRange represents error when loan sum is out of program limits.
+var ErrLoanSumOutOfRange = errors.New("the loan sum is out of program limits")
+
+// ErrObjectCostTooHigh represents error when object cost exceeds program limit.
+var ErrObjectCostTooHigh = errors.New("the object cost is too high")
+
+// codeErrors maps violation codes to errors they match.
+var codeErrors = map[string]error{
+	CodeInitialPaymentRange:    ErrInitialPaymentOutOfRange,
+	CodeMinInitialPaymentRatio: ErrInsufficientInitialPayment,
+	CodeMinMonths:              ErrTermOutOfRange,
+	CodeMaxMonths:              ErrTermOutOfRange,
+	CodeMinLoan:                ErrLoanSumOutOfRange,
+	CodeMaxLoan:                ErrLoanSumOutOfRange,
+	CodeMaxObjectCost:          ErrObjectCostTooHigh,
+}
+
+// EligibilityError holds every rule violated by calculation params.
+// It matches ErrNotEligible and errors of every violation code.
+type EligibilityError struct {
+	Violations []dto.Violation
+}
+
+// Error joins messages of all violations.
+func (e *EligibilityError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Is reports whether target is ErrNotEligible or error of any violation code.
+func (e *EligibilityError) Is(target error) bool {
+	if target == ErrNotEligible { //nolint:errorlint // sentinel comparison
+		return true
+	}
+
+	for _, v := range e.Violations {
+		if codeErrors[v.Code] == target { //nolint:errorlint // sentinel comparison
+			return true
+		}
+	}
+
+	return false
+}
+
+// Rule checks single program constraint and returns violation or nil if constraint is satisfied.
+type Rule func(program dto.Program, params dto.CalcParams) *dto.Violation
+
+// DefaultRules returns rules evaluated for every calculation.
+// Zero value of program limit disables corresponding rule.
+func DefaultRules() []Rule {
+	return []Rule{
+		initialPaymentRangeRule,
+		minInitialPaymentRatioRule,
+		minMonthsRule,
+		maxMonthsRule,
+		minLoanRule,
+		maxLoanRule,
+		maxObjectCostRule,
+	}
+}
+
+// checkRules evaluates every rule and returns EligibilityError if any of them is violated.
+func checkRules(rules []Rule, program dto.Program, params dto.CalcParams) error {
+	var violations []dto.Violation
+	for _, rule := range rules {
+		if v := rule(program, params); v != nil {
+			violations = append(violations, *v)
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &EligibilityError{Violations: violations}
+}
+
+// initialPaymentRangeRule requires initial payment within [0, object cost), so amounts of other rules are meaningful.
+func initialPaymentRangeRule(_ dto.Program, params dto.CalcParams) *dto.Violation {
+	if params.InitialPayment < 0 {
+		return violation(CodeInitialPaymentRange, "initial_payment", 0, float64(params.InitialPayment))
+	}
+	if params.InitialPayment >= params.ObjectCost {
+		return violation(CodeInitialPaymentRange, "initial_payment", float64(params.ObjectCost), float64(params.InitialPayment))
+	}
+
+	return nil
+}
+
+func minInitialPaymentRatioRule(program dto.Program, params dto.CalcParams) *dto.Violation {
+	ratio := float64(params.InitialPayment) / float64(params.ObjectCost)
+	if ratio >= program.MinInitialPaymentRatio {
+		return nil
+	}
+
+	return violation(CodeMinInitialPaymentRatio, "initial_payment", program.MinInitialPaymentRatio, ratio)
+}
+
+func minMonthsRule(program dto.Program, params dto.CalcParams) *dto.Violation {
+	minMonths := max(program.MinMonths, 1)
+	if params.Months >= minMonths {
+		return nil
+	}
+
+	return violation(CodeMinMonths, "months", float64(minMonths), float64(params.Months))
+}
+
+// maxMonthsRule limits term by dto.MaxMonths when program has no maximum.
+func maxMonthsRule(program dto.Program, params dto.CalcParams) *dto.Violation {
+	maxMonths := program.MaxMonths
+	if maxMonths == 0 || maxMonths > dto.MaxMonths {
+		maxMonths = dto.MaxMonths
+	}
+
+	if params.Months <= maxMonths {
+		return nil
+	}
+
+	return violation(CodeMaxMonths, "months", float64(maxMonths), float64(params.Months))
+}
+
+// minLoanRule requires positive loan sum even when program has no minimum, as there is nothing to schedule otherwise.
+func minLoanRule(program dto.Program, params dto.CalcParams) *dto.Violation {
+	minLoan := max(program.MinLoan, 1)
+
+	loanSum := params.ObjectCost - params.InitialPayment
+	if loanSum >= minLoan {
+		return nil
+	}
+
+	return violation(CodeMinLoan, "initial_payment", float64(minLoan), float64(loanSum))
+}
+
+func maxLoanRule(program dto.Program, params dto.CalcParams) *dto.Violation {
+	loanSum := params.ObjectCost - params.InitialPayment
+	if program.MaxLoan == 0 || loanSum <= program.MaxLoan {
+		return nil
+	}
+
+	return violation(CodeMaxLoan, "initial_payment", float64(program.MaxLoan), float64(loanSum))
+}
+
+func maxObjectCostRule(program dto.Program, params dto.CalcParams) *dto.Violation {
+	if program.MaxObjectCost == 0 || params.ObjectCost <= program.MaxObjectCost {
+		return nil
+	}
+
+	return violation(CodeMaxObjectCost, "object_cost", float64(program.MaxObjectCost), float64(params.ObjectCost))
+}
+
+func violation(code, field string, limit, actual float64) *dto.Violation {
+	return &dto.Violation{
+		Code:    code,
+		Field:   field,
+		Message: codeErrors[code].Error(),
+		Limit:   limit,
+		Actual:  actual,
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"github.com/stretchr/testify/require"
+	"mortgage-calculator/src/internal/domain/dto"
+	"testing"
+)
+
+func TestCheckRules(t *testing.T) {
+	program := dto.Program{
+		ID:                     "family",
+		Rate:                   0.06,
+		MinInitialPaymentRatio: 0.1,
+		MinMonths:              12,
+		MaxMonths:              360,
+		MinLoan:                100000,
+		MaxLoan:                6000000,
+		MaxObjectCost:          9000000,
+	}
+
+	cases := []struct {
+		params dto.CalcParams
+		codes  []string
+	}{
+		{dto.CalcParams{ObjectCost: 1000000, InitialPayment: 100000, Months: 120}, nil},
+		{dto.CalcParams{ObjectCost: 1000000, InitialPayment: 99999, Months: 120}, []string{CodeMinInitialPaymentRatio}},
+		{dto.CalcParams{ObjectCost: 1000000, InitialPayment: 100000, Months: 11}, []string{CodeMinMonths}},
+		{dto.CalcParams{ObjectCost: 1000000, InitialPayment: 100000, Months: 361}, []string{CodeMaxMonths}},
+		{dto.CalcParams{ObjectCost: 100000, InitialPayment: 20000, Months: 120}, []string{CodeMinLoan}},
+		{dto.CalcParams{ObjectCost: 9000000, InitialPayment: 2000000, Months: 120}, []string{CodeMaxLoan}},
+		{dto.CalcParams{ObjectCost: 10000000, InitialPayment: 5000000, Months: 120}, []string{CodeMaxObjectCost}},
+		{dto.CalcParams{ObjectCost: -100000, InitialPayment: -200000, Months: 120}, []string{CodeInitialPaymentRange}},
+		{dto.CalcParams{ObjectCost: 1000000, InitialPayment: 1000000, Months: 120}, []string{CodeInitialPaymentRange, CodeMinLoan}},
+		{
+			dto.CalcParams{ObjectCost: 20000000, InitialPayment: 1000000, Months: 400},
+			[]string{CodeMinInitialPaymentRatio, CodeMaxMonths, CodeMaxLoan, CodeMaxObjectCost},
+		},
+	}
+
+	for _, tt := range cases {
+		err := checkRules(DefaultRules(), program, tt.params)
+		if tt.codes == nil {
+			require.NoError(t, err)
+			continue
+		}
+
+		var eligibilityErr *EligibilityError
+		require.ErrorAs(t, err, &eligibilityErr)
+		require.ErrorIs(t, err, ErrNotEligible)
+
+		codes := make([]string, len(eligibilityErr.Violations))
+		for i, v := range eligibilityErr.Violations {
+			codes[i] = v.Code
+			require.ErrorIs(t, err, codeErrors[v.Code])
+		}
+		require.Equal(t, tt.codes, codes)
+	}
+}
+
+func TestCheckRules_NoLimits(t *testing.T) {
+	err := checkRules(DefaultRules(), dto.Program{ID: "base", Rate: 0.1}, dto.CalcParams{
+		ObjectCost:     100000000000,
+		InitialPayment: 1,
+		Months:         1200,
+	})
+	require.NoError(t, err)
+}
+
+func TestCheckRules_DefaultTermLimits(t *testing.T) {
+	program := dto.Program{ID: "base", Rate: 0.1}
+
+	for _, months := range []int{0, dto.MaxMonths + 1} {
+		err := checkRules(DefaultRules(), program, dto.CalcParams{ObjectCost: 1000000, InitialPayment: 200000, Months: months})
+		require.ErrorIs(t, err, ErrTermOutOfRange)
+	}
+}
+
+func TestEligibilityError(t *testing.T) {
+	err := &EligibilityError{
+		Violations: []dto.Violation{
+			*violation(CodeMinInitialPaymentRatio, "initial_payment", 0.2, 0.1),
+			*violation(CodeMaxMonths, "months", 360, 400),
+		},
+	}
+
+	require.Equal(t, "the initial payment should be more; the term is out of program limits", err.Error())
+	require.ErrorIs(t, err, ErrInsufficientInitialPayment)
+	require.ErrorIs(t, err, ErrTermOutOfRange)
+	require.NotErrorIs(t, err, ErrLoanSumOutOfRange)
+	require.Equal(t, dto.Violation{
+		Code:    CodeMaxMonths,
+		Field:   "months",
+		Message: ErrTermOutOfRange.Error(),
+		Limit:   360,
+		Actual:  400,
+	}, err.Violations[1])
+}
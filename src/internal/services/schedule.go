@@ -0,0 +1,254 @@
+package services
+
+import (
+	"math/big"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/money"
+	"time"
+)
+
+// monthlyRateDen is a denominator of monthly rate expressed by annual rate in basis points.
+const monthlyRateDen = 12 * money.BasisPoints
+
+// loan holds values common to every payment type.
+type loan struct {
+	start      time.Time
+	rate       money.Rate     // annual rate
+	rounding   money.Rounding // rounding of interest to minor units
+	S          money.Amount   // mortgage debt (loan sum)
+	T          int            // interest periods count
+	repayments map[int][]dto.EarlyRepayment
+}
+
+func newLoan(params dto.CalcParams, rate money.Rate, rounding money.Rounding, start time.Time) *loan {
+	repayments := make(map[int][]dto.EarlyRepayment, len(params.EarlyRepayments))
+	for _, r := range params.EarlyRepayments {
+		repayments[r.Month] = append(repayments[r.Month], r)
+	}
+
+	return &loan{
+		start:      start,
+		rate:       rate,
+		rounding:   rounding,
+		S:          params.ObjectCost - params.InitialPayment, // mortgage debt (loan sum)
+		T:          params.Months,                             // interest periods count
+		repayments: repayments,
+	}
+}
+
+// result builds schedule of given payment type and its aggregates.
+// money.ErrOverflow is returned when any amount of schedule doesn't fit money.Amount.
+func (l *loan) result(paymentType string, currency money.Currency) (*dto.CalcSchedule, error) {
+	var (
+		payments []dto.SchedulePayment
+		err      error
+	)
+	switch paymentType {
+	case dto.PaymentTypeDifferentiated:
+		payments, err = l.differentiated()
+	default:
+		payments, err = l.annuity()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// schedule of zero loan sum is empty, its aggregates are zero
+	var first, last dto.SchedulePayment
+	if len(payments) > 0 {
+		first, last = payments[0], payments[len(payments)-1]
+	}
+
+	totals, err := totalsOf(payments)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CalcSchedule{
+		Aggregates: dto.CalcAggregates{
+			LastPaymentDate: last.PaymentDate,
+			PaymentType:     paymentType,
+			Currency:        currency,
+			Rate:            l.rate.Percent(),
+			RateBps:         l.rate,
+			LoanSum:         l.S,
+			MonthlyPayment:  first.Payment,
+			FirstPayment:    first.Payment,
+			LastPayment:     last.Payment,
+			MaxPayment:      maxPaymentOf(payments),
+			Overpayment:     totals.Interest,
+		},
+		Payments: payments,
+		Totals:   totals,
+	}, nil
+}
+
+func (l *loan) paymentDate(month int) time.Time {
+	return l.start.AddDate(0, month, 0)
+}
+
+// annuity builds schedule of equal monthly payments.
+// The last scheduled payment repays the rest of debt, so it settles the rounding difference of the payment.
+// Early repayment either keeps payment and shortens the term or recalculates payment for the remaining term.
+func (l *loan) annuity() ([]dto.SchedulePayment, error) {
+	PM, err := l.annuityPayment(l.S, l.T) // monthly payment
+	if err != nil {
+		return nil, err
+	}
+
+	payments := make([]dto.SchedulePayment, 0, l.T)
+	balance := l.S
+
+	for month := 1; month <= l.T && balance > 0; month++ {
+		interest, err := l.interest(balance)
+		if err != nil {
+			return nil, err
+		}
+		principal := PM - interest
+
+		if principal > balance || month == l.T {
+			principal = balance
+		}
+
+		p, err := l.payment(month, principal, interest, balance-principal)
+		if err != nil {
+			return nil, err
+		}
+		if l.repay(&p) && p.Balance > 0 {
+			if PM, err = l.annuityPayment(p.Balance, l.T-month); err != nil {
+				return nil, err
+			}
+		}
+
+		balance = p.Balance
+		payments = append(payments, p)
+	}
+
+	return payments, nil
+}
+
+// differentiated builds schedule of equal principal parts and declining interest.
+// The remainder of principal division is settled by the last scheduled payment.
+// Early repayment either keeps principal part and shortens the term or splits the rest of debt over the remaining term.
+func (l *loan) differentiated() ([]dto.SchedulePayment, error) {
+	payments := make([]dto.SchedulePayment, 0, l.T)
+	balance := l.S
+	principalPart := l.S / money.Amount(l.T)
+
+	for month := 1; month <= l.T && balance > 0; month++ {
+		interest, err := l.interest(balance)
+		if err != nil {
+			return nil, err
+		}
+		principal := principalPart
+
+		if principal > balance || month == l.T {
+			principal = balance
+		}
+
+		p, err := l.payment(month, principal, interest, balance-principal)
+		if err != nil {
+			return nil, err
+		}
+		if l.repay(&p) && p.Balance > 0 {
+			principalPart = p.Balance / money.Amount(l.T-month)
+		}
+
+		balance = p.Balance
+		payments = append(payments, p)
+	}
+
+	return payments, nil
+}
+
+// repay applies early repayments of the payment month and reports whether payment should be recalculated.
+func (l *loan) repay(p *dto.SchedulePayment) bool {
+	var recalculate bool
+	for _, r := range l.repayments[p.Month] {
+		amount := min(r.Amount, p.Balance)
+		p.EarlyRepayment += amount
+		p.Balance -= amount
+
+		if r.Strategy == dto.StrategyReducePayment {
+			recalculate = true
+		}
+	}
+
+	return recalculate
+}
+
+func (l *loan) payment(month int, principal, interest, balance money.Amount) (dto.SchedulePayment, error) {
+	payment, err := money.Add(principal, interest)
+	if err != nil {
+		return dto.SchedulePayment{}, err //nolint:wrapcheck // wrapped by caller
+	}
+
+	return dto.SchedulePayment{
+		Month:       month,
+		PaymentDate: l.paymentDate(month).Format(dto.DateFormat),
+		Payment:     payment,
+		Principal:   principal,
+		Interest:    interest,
+		Balance:     balance,
+	}, nil
+}
+
+// interest calculates interest accrued on balance for one month.
+func (l *loan) interest(balance money.Amount) (money.Amount, error) {
+	return money.MulDiv(balance, int64(l.rate), monthlyRateDen, l.rounding) //nolint:wrapcheck // wrapped by caller
+}
+
+// annuityPayment calculates annuity payment for loan sum S and periods count T
+// as S*G*(1+G)^T/((1+G)^T-1), where G is monthly rate, using exact rational arithmetic.
+// Payment is always rounded up, so the last payment doesn't grow by accumulated rounding difference.
+func (l *loan) annuityPayment(S money.Amount, T int) (money.Amount, error) {
+	if l.rate == 0 {
+		return money.Quo(big.NewInt(int64(S)), big.NewInt(int64(T)), money.RoundUp) //nolint:wrapcheck // wrapped by caller
+	}
+
+	// G = rate / den, so (1+G)^T = (den+rate)^T / den^T
+	den := big.NewInt(monthlyRateDen)
+	grown := new(big.Int).Add(den, big.NewInt(int64(l.rate)))
+
+	exp := big.NewInt(int64(T))
+	grownT := new(big.Int).Exp(grown, exp, nil)
+	denT := new(big.Int).Exp(den, exp, nil)
+
+	num := new(big.Int).Mul(big.NewInt(int64(S)), big.NewInt(int64(l.rate)))
+	num.Mul(num, grownT)
+
+	div := new(big.Int).Sub(grownT, denT)
+	div.Mul(div, den)
+
+	return money.Quo(num, div, money.RoundUp) //nolint:wrapcheck // wrapped by caller
+}
+
+func totalsOf(payments []dto.SchedulePayment) (dto.ScheduleTotals, error) {
+	var (
+		totals dto.ScheduleTotals
+		err    error
+	)
+	add := func(total *money.Amount, v money.Amount) {
+		if err == nil {
+			*total, err = money.Add(*total, v)
+		}
+	}
+
+	for _, p := range payments {
+		add(&totals.Payment, p.Payment)
+		add(&totals.Principal, p.Principal)
+		add(&totals.Interest, p.Interest)
+		add(&totals.EarlyRepayment, p.EarlyRepayment)
+	}
+
+	return totals, err
+}
+
+func maxPaymentOf(payments []dto.SchedulePayment) money.Amount {
+	var res money.Amount
+	for _, p := range payments {
+		res = max(res, p.Payment)
+	}
+
+	return res
+}
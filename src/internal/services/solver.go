@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/money"
+	"mortgage-calculator/src/internal/logger"
+	"sort"
+	"time"
+)
+
+// ErrPaymentTooLow represents error when no loan satisfies the monthly payment limit.
+var ErrPaymentTooLow = errors.New("the monthly payment is too low")
+
+// MaxLoan finds maximum loan sum and object cost whose monthly payment does not exceed payment.
+// Params must contain initial payment and months, object cost is ignored.
+// Loan sum is also limited by program rules, so found params are always eligible for the program.
+func (s *CalculatorService) MaxLoan(
+	ctx context.Context,
+	payment money.Amount,
+	params dto.CalcParams,
+	program dto.CalcProgram,
+) (*dto.Solution, error) {
+	const op = "calculatorService.MaxLoan"
+	log := logger.FromContext(ctx, s.log).With(slog.String("op", op))
+
+	p, err := s.program(log, program)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	start, err := s.startDate(log, &params)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("searching maximum loan sum", slog.Int64("payment", int64(payment)), slog.Int("months", params.Months))
+
+	params.EarlyRepayments = nil
+	paymentType := paymentTypeOf(params)
+
+	// loan sum whose amounts overflow exceeds any payment
+	exceeds := func(loanSum money.Amount, paymentOf func(*dto.CalcAggregates) money.Amount) bool {
+		params.ObjectCost = params.InitialPayment + loanSum
+		res, err := s.newLoan(params, p, start).result(paymentType, s.currency)
+
+		return err != nil || paymentOf(&res.Aggregates) > payment
+	}
+
+	limit := loanSumLimit(p, params.InitialPayment)
+
+	// the first payment grows with the loan sum, loan sums above program limit are cut anyway,
+	// object cost is limited by dto.MaxAmount, so it never overflows
+	bound := min(loanSumBound(payment, params.Months), dto.MaxAmount-params.InitialPayment)
+	if limit > 0 {
+		bound = min(bound, limit)
+	}
+
+	n := sort.Search(int(bound), func(i int) bool {
+		return exceeds(money.Amount(i+1), firstPaymentOf)
+	})
+
+	// the last payment may exceed the first one by rounding difference
+	loanSum := money.Amount(n)
+	for loanSum > 0 && exceeds(loanSum, highestPaymentOf) {
+		loanSum--
+	}
+
+	if loanSum == 0 {
+		log.Warn("no loan satisfies payment limit")
+
+		return nil, fmt.Errorf("%s: %w", op, ErrPaymentTooLow)
+	}
+
+	params.ObjectCost = params.InitialPayment + min(loanSum, limit)
+
+	res, err := s.solution(log, p, params, start)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// MinTerm finds minimal term whose monthly payment does not exceed payment.
+// Params must contain object cost and initial payment, months are ignored.
+// Term is searched within program limits.
+func (s *CalculatorService) MinTerm(
+	ctx context.Context,
+	payment money.Amount,
+	params dto.CalcParams,
+	program dto.CalcProgram,
+) (*dto.Solution, error) {
+	const op = "calculatorService.MinTerm"
+	log := logger.FromContext(ctx, s.log).With(slog.String("op", op))
+
+	p, err := s.program(log, program)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	start, err := s.startDate(log, &params)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	minMonths := max(1, p.MinMonths)
+	maxMonths := p.MaxMonths
+	if maxMonths == 0 || maxMonths > dto.MaxMonths {
+		maxMonths = dto.MaxMonths
+	}
+
+	log.Info(
+		"searching minimal term",
+		slog.Int64("payment", int64(payment)),
+		slog.Int("min_months", minMonths),
+		slog.Int("max_months", maxMonths),
+	)
+
+	params.EarlyRepayments = nil
+	paymentType := paymentTypeOf(params)
+
+	// term whose amounts overflow exceeds any payment
+	exceeds := func(months int, paymentOf func(*dto.CalcAggregates) money.Amount) bool {
+		params.Months = months
+		res, err := s.newLoan(params, p, start).result(paymentType, s.currency)
+
+		return err != nil || paymentOf(&res.Aggregates) > payment
+	}
+
+	// the first payment decreases as the term grows
+	months := minMonths + sort.Search(maxMonths-minMonths+1, func(i int) bool {
+		return !exceeds(minMonths+i, firstPaymentOf)
+	})
+
+	// the last payment may exceed the first one by rounding difference
+	for months <= maxMonths && exceeds(months, highestPaymentOf) {
+		months++
+	}
+
+	if months > maxMonths {
+		log.Warn("no term satisfies payment limit")
+
+		return nil, fmt.Errorf("%s: %w", op, ErrPaymentTooLow)
+	}
+
+	params.Months = months
+
+	res, err := s.solution(log, p, params, start)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// solution checks found params against program rules and calculates their aggregates.
+func (s *CalculatorService) solution(
+	log *slog.Logger,
+	p dto.Program,
+	params dto.CalcParams,
+	start time.Time,
+) (*dto.Solution, error) {
+	if err := s.checkRules(log, p, params); err != nil {
+		return nil, err
+	}
+
+	// program limits may leave no room for a loan
+	if params.ObjectCost <= params.InitialPayment {
+		log.Warn("program limits allow no loan")
+
+		return nil, ErrLoanSumOutOfRange
+	}
+
+	res, err := s.result(log, s.newLoan(params, p, start), paymentTypeOf(params))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info(
+		"solution found",
+		slog.Int64("object_cost", int64(params.ObjectCost)),
+		slog.Int64("loan_sum", int64(res.Aggregates.LoanSum)),
+		slog.Int("months", params.Months),
+		slog.Int64("max_payment", int64(res.Aggregates.MaxPayment)),
+	)
+
+	return &dto.Solution{
+		Params:     params,
+		Aggregates: res.Aggregates,
+	}, nil
+}
+
+// firstPaymentOf and highestPaymentOf select payment compared with the limit by solver.
+func firstPaymentOf(a *dto.CalcAggregates) money.Amount { return a.FirstPayment }
+
+func highestPaymentOf(a *dto.CalcAggregates) money.Amount { return a.MaxPayment }
+
+// loanSumBound returns upper bound of loan sum paid off by payment in months.
+// Payments cover at least the loan sum, so it never exceeds payment * months, the product saturates on overflow.
+func loanSumBound(payment money.Amount, months int) money.Amount {
+	if payment <= 0 || months <= 0 {
+		return 0
+	}
+	if payment > math.MaxInt64/money.Amount(months) {
+		return math.MaxInt64
+	}
+
+	return payment * money.Amount(months)
+}
+
+// loanSumLimit returns maximum loan sum allowed by program for given initial payment.
+func loanSumLimit(p dto.Program, initialPayment money.Amount) money.Amount {
+	res := money.Amount(math.MaxInt64)
+	if p.MaxLoan > 0 {
+		res = min(res, p.MaxLoan)
+	}
+	if p.MaxObjectCost > 0 {
+		res = min(res, p.MaxObjectCost-initialPayment)
+	}
+	if p.MinInitialPaymentRatio > 0 {
+		objectCost := money.Amount(float64(initialPayment) / p.MinInitialPaymentRatio)
+		// float division may overshoot the ratio by one
+		for objectCost > initialPayment && float64(initialPayment)/float64(objectCost) < p.MinInitialPaymentRatio {
+			objectCost--
+		}
+		res = min(res, objectCost-initialPayment)
+	}
+
+	return res
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"math"
+	"mortgage-calculator/src/internal/domain/dto"
+	"mortgage-calculator/src/internal/lib/money"
+	"testing"
+)
+
+func TestCalculatorService_MaxLoan(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, testClock)
+
+	cases := []struct {
+		payment        money.Amount
+		initialPayment money.Amount
+		paymentType    string
+	}{
+		{33458, 3000000, dto.PaymentTypeAnnuity},
+		{50000, 5000000, dto.PaymentTypeAnnuity},
+		{50000, 5000000, dto.PaymentTypeDifferentiated},
+	}
+
+	for _, tt := range cases {
+		params := dto.CalcParams{
+			InitialPayment: tt.initialPayment,
+			Months:         240,
+			PaymentType:    tt.paymentType,
+		}
+
+		res, err := service.MaxLoan(ctx, tt.payment, params, dto.CalcProgram{Salary: true})
+		require.NoError(t, err)
+		require.Equal(t, res.Params.ObjectCost-tt.initialPayment, res.Aggregates.LoanSum)
+		require.LessOrEqual(t, res.Aggregates.MaxPayment, tt.payment)
+
+		// one more unit of loan exceeds the payment
+		params.ObjectCost = res.Params.ObjectCost + 1
+		next, err := service.Calculate(ctx, params, dto.CalcProgram{Salary: true})
+		require.NoError(t, err)
+		require.Greater(t, next.MaxPayment, tt.payment)
+	}
+}
+
+func TestCalculatorService_MaxLoan_ProgramLimits(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, []dto.Program{
+		{ID: "base", Rate: 0.1, MinInitialPaymentRatio: 0.2},
+		{ID: "capped", Rate: 0.1, MaxLoan: 1500000},
+		{ID: "big", Rate: 0.1, MinLoan: 10000000},
+		{ID: "cheap", Rate: 0.1, MaxObjectCost: 1000000},
+	}, DefaultCurrency, DefaultRounding, testClock)
+
+	params := dto.CalcParams{InitialPayment: 1000000, Months: 240}
+
+	// initial payment covers only 20% of object cost
+	res, err := service.MaxLoan(ctx, 1000000, params, dto.CalcProgram{ID: "base"})
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(5000000), res.Params.ObjectCost)
+	require.Equal(t, money.Amount(4000000), res.Aggregates.LoanSum)
+
+	res, err = service.MaxLoan(ctx, 1000000, params, dto.CalcProgram{ID: "capped"})
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(1500000), res.Aggregates.LoanSum)
+
+	res, err = service.MaxLoan(ctx, 10000, params, dto.CalcProgram{ID: "big"})
+	require.ErrorIs(t, err, ErrNotEligible)
+	require.ErrorIs(t, err, ErrLoanSumOutOfRange)
+	require.Empty(t, res)
+
+	res, err = service.MaxLoan(ctx, 10000, params, dto.CalcProgram{ID: "cheap"})
+	require.ErrorIs(t, err, ErrLoanSumOutOfRange)
+	require.Empty(t, res)
+
+	// payment * months overflows, loan sum is still limited by program
+	res, err = service.MaxLoan(ctx, math.MaxInt64/2, params, dto.CalcProgram{ID: "capped"})
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(1500000), res.Aggregates.LoanSum)
+
+	res, err = service.MaxLoan(ctx, 10000, params, dto.CalcProgram{ID: "family"})
+	require.ErrorIs(t, err, ErrUnknownProgram)
+	require.Empty(t, res)
+}
+
+func TestCalculatorService_MinTerm(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, DefaultPrograms(), DefaultCurrency, DefaultRounding, testClock)
+
+	params := dto.CalcParams{ObjectCost: 5000000, InitialPayment: 1000000}
+
+	res, err := service.MinTerm(ctx, 33458, params, dto.CalcProgram{Salary: true})
+	require.NoError(t, err)
+	require.Equal(t, 240, res.Params.Months)
+	require.Equal(t, "2024-06-18", res.Params.StartDate)
+	require.Equal(t, money.Amount(33458), res.Aggregates.MonthlyPayment)
+
+	res, err = service.MinTerm(ctx, 33457, params, dto.CalcProgram{Salary: true})
+	require.NoError(t, err)
+	require.Equal(t, 241, res.Params.Months)
+
+	res, err = service.MinTerm(ctx, 26000, params, dto.CalcProgram{Salary: true})
+	require.ErrorIs(t, err, ErrPaymentTooLow)
+	require.Empty(t, res)
+}
+
+func TestCalculatorService_MinTerm_ProgramLimits(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+	service := NewCalculatorService(log, []dto.Program{
+		{ID: "short", Rate: 0.1, MinMonths: 120, MaxMonths: 180},
+	}, DefaultCurrency, DefaultRounding, testClock)
+
+	params := dto.CalcParams{ObjectCost: 5000000, InitialPayment: 1000000}
+
+	// payment is enough for a shorter term than program allows
+	res, err := service.MinTerm(ctx, 1000000, params, dto.CalcProgram{ID: "short"})
+	require.NoError(t, err)
+	require.Equal(t, 120, res.Params.Months)
+
+	res, err = service.MinTerm(ctx, 40000, params, dto.CalcProgram{ID: "short"})
+	require.ErrorIs(t, err, ErrPaymentTooLow)
+	require.Empty(t, res)
+}
+
+func TestLoanSumBound(t *testing.T) {
+	require.Equal(t, money.Amount(24000), loanSumBound(100, 240))
+	require.Equal(t, money.Amount(0), loanSumBound(100, 0))
+	require.Equal(t, money.Amount(math.MaxInt64), loanSumBound(math.MaxInt64/2, 240))
+}
+
+func TestLoanSumLimit(t *testing.T) {
+	cases := []struct {
+		program        dto.Program
+		initialPayment money.Amount
+		want           money.Amount
+	}{
+		{dto.Program{}, 1000000, math.MaxInt64},
+		{dto.Program{MinInitialPaymentRatio: 0.2}, 1000000, 4000000},
+		{dto.Program{MinInitialPaymentRatio: 0.3}, 1000000, 2333333},
+		{dto.Program{MinInitialPaymentRatio: 0.2, MaxLoan: 3000000}, 1000000, 3000000},
+		{dto.Program{MinInitialPaymentRatio: 0.2, MaxObjectCost: 3000000}, 1000000, 2000000},
+	}
+
+	for _, tt := range cases {
+		require.Equal(t, tt.want, loanSumLimit(tt.program, tt.initialPayment))
+	}
+}